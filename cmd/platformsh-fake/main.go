@@ -0,0 +1,44 @@
+// Command platformsh-fake serves the in-memory fake Platform.sh API, so that
+// the provider can be planned and applied without network access.
+//
+// Point the provider at it with api_url and auth_url (or PLATFORMSH_API_URL
+// and PLATFORMSH_AUTH_URL) set to the address it prints.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh/fake"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	fixturePath := flag.String("fixture", "", "JSON file with the initial projects and environments")
+	activityPolls := flag.Int("activity-polls", 1, "number of polls before an activity completes")
+	flag.Parse()
+
+	fixture := &fake.Fixture{}
+	if *fixturePath != "" {
+		var err error
+		fixture, err = fake.LoadFixture(*fixturePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	server, err := fake.Listen(*addr, fixture, fake.WithActivityPolls(*activityPolls))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer server.Close()
+
+	log.Printf("Fake Platform.sh API listening on %s", server.URL)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+}
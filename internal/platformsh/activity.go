@@ -0,0 +1,236 @@
+package platformsh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultActivityPollInterval is how often a pending activity is polled.
+const DefaultActivityPollInterval = 3 * time.Second
+
+// activityLogExcerptLines is the number of trailing log lines included in
+// an ActivityError.
+const activityLogExcerptLines = 20
+
+// Activity states and results reported by the API.
+const (
+	ActivityStatePending    = "pending"
+	ActivityStateInProgress = "in_progress"
+	ActivityStateComplete   = "complete"
+	ActivityStateCancelled  = "cancelled"
+
+	ActivityResultSuccess = "success"
+	ActivityResultFailure = "failure"
+)
+
+// Activity is an asynchronous operation on a project, such as branching,
+// activating or deleting an environment.
+type Activity struct {
+	ID                string   `json:"id"`
+	Type              string   `json:"type"`
+	State             string   `json:"state"`
+	Result            string   `json:"result"`
+	Description       string   `json:"description"`
+	CompletionPercent int      `json:"completion_percent"`
+	Project           string   `json:"project"`
+	Environments      []string `json:"environments"`
+	Log               string   `json:"log"`
+	CreatedAt         string   `json:"created_at"`
+	CompletedAt       string   `json:"completed_at"`
+}
+
+// IsFinished reports whether the activity has reached a terminal state.
+func (a *Activity) IsFinished() bool {
+	return a.State == ActivityStateComplete || a.State == ActivityStateCancelled
+}
+
+// Succeeded reports whether the activity finished successfully.
+func (a *Activity) Succeeded() bool {
+	return a.State == ActivityStateComplete && a.Result == ActivityResultSuccess
+}
+
+// LogExcerpt returns the last lines of the activity log.
+func (a *Activity) LogExcerpt() string {
+	lines := strings.Split(strings.TrimRight(a.Log, "\n"), "\n")
+	if len(lines) > activityLogExcerptLines {
+		lines = lines[len(lines)-activityLogExcerptLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ActivityError is returned when an activity is cancelled or fails.
+type ActivityError struct {
+	Activity *Activity
+}
+
+func (e *ActivityError) Error() string {
+	a := e.Activity
+	outcome := "failed"
+	if a.State == ActivityStateCancelled {
+		outcome = "was cancelled"
+	}
+
+	msg := fmt.Sprintf("activity %s (%s) %s", a.ID, a.Type, outcome)
+	if excerpt := a.LogExcerpt(); excerpt != "" {
+		msg += ", log excerpt:\n" + excerpt
+	}
+	return msg
+}
+
+// OperationResponse is returned by requests that change an object. Changes
+// that take effect asynchronously carry the activities to wait for.
+type OperationResponse struct {
+	Status   string `json:"status"`
+	Code     int    `json:"code"`
+	Embedded struct {
+		Activities []Activity `json:"activities"`
+	} `json:"_embedded"`
+}
+
+// Activities returns the activities started by the operation.
+func (r *OperationResponse) Activities() []Activity {
+	if r == nil {
+		return nil
+	}
+	return r.Embedded.Activities
+}
+
+func (c *Client) GetActivity(ctx context.Context, projectID, activityID string) (*Activity, error) {
+	var activity Activity
+	req := c.request(ctx).
+		SetPathParams(map[string]string{
+			"projectId":  projectID,
+			"activityId": activityID,
+		}).
+		SetResult(&activity)
+
+	if _, err := c.execute(req, resty.MethodGet, "/projects/{projectId}/activities/{activityId}"); err != nil {
+		return nil, err
+	}
+
+	return &activity, nil
+}
+
+// WaitForActivity polls an activity until it finishes and returns its final
+// state. An ActivityError is returned along with the activity when it did
+// not succeed.
+func (c *Client) WaitForActivity(ctx context.Context, projectID, activityID string) (*Activity, error) {
+	ticker := time.NewTicker(c.activityPollInterval)
+	defer ticker.Stop()
+
+	for {
+		activity, err := c.GetActivity(ctx, projectID, activityID)
+		if err != nil {
+			return nil, fmt.Errorf("polling activity %s: %w", activityID, err)
+		}
+		if activity.IsFinished() {
+			if !activity.Succeeded() {
+				return activity, &ActivityError{Activity: activity}
+			}
+			return activity, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return activity, fmt.Errorf("waiting for activity %s: %w", activityID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForOperation waits for every activity started by op, in order, and
+// stops at the first one that does not succeed.
+func (c *Client) WaitForOperation(ctx context.Context, projectID string, op *OperationResponse) ([]Activity, error) {
+	// Environments read while the activities ran are stale now.
+	defer c.invalidateEnvironments(projectID)
+
+	var finished []Activity
+	for _, activity := range op.Activities() {
+		result := &activity
+		if !activity.IsFinished() {
+			var err error
+			result, err = c.WaitForActivity(ctx, projectID, activity.ID)
+			if err != nil {
+				return finished, err
+			}
+		} else if !activity.Succeeded() {
+			return finished, &ActivityError{Activity: result}
+		}
+		finished = append(finished, *result)
+	}
+
+	return finished, nil
+}
+
+// WaitForEnvironment waits while an environment is dirty, that is while an
+// activity such as a deployment after a git push runs on it, and returns
+// the environment once it has settled.
+func (c *Client) WaitForEnvironment(ctx context.Context, projectID, environmentID string) (*Environment, error) {
+	ticker := time.NewTicker(c.activityPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// The cached list would report the same status until it expires.
+		c.invalidateEnvironments(projectID)
+		env, err := c.GetEnvironment(ctx, projectID, environmentID)
+		if err != nil {
+			return nil, fmt.Errorf("polling environment %s: %w", environmentID, err)
+		}
+		if env.Status != EnvironmentStatusDirty {
+			return env, nil
+		}
+
+		activities, err := c.runningActivities(ctx, projectID, environmentID)
+		if err != nil {
+			return nil, fmt.Errorf("listing activities of environment %s: %w", environmentID, err)
+		}
+		for _, activity := range activities {
+			// A failed deployment settles the environment all the same.
+			var activityErr *ActivityError
+			if _, err := c.WaitForActivity(ctx, projectID, activity.ID); err != nil && !errors.As(err, &activityErr) {
+				return nil, err
+			}
+		}
+		if len(activities) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return env, fmt.Errorf("waiting for environment %s: %w", environmentID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// runningActivities returns the unfinished activities of an environment.
+func (c *Client) runningActivities(ctx context.Context, projectID, environmentID string) ([]Activity, error) {
+	var activities []Activity
+	req := c.request(ctx).
+		SetPathParams(map[string]string{
+			"projectId":     projectID,
+			"environmentId": environmentID,
+		}).
+		SetQueryParamsFromValues(url.Values{
+			"state": {ActivityStatePending, ActivityStateInProgress},
+		}).
+		SetResult(&activities)
+
+	if _, err := c.execute(req, resty.MethodGet, "/projects/{projectId}/environments/{environmentId}/activities"); err != nil {
+		return nil, err
+	}
+
+	running := activities[:0]
+	for _, activity := range activities {
+		if !activity.IsFinished() {
+			running = append(running, activity)
+		}
+	}
+	return running, nil
+}
@@ -0,0 +1,30 @@
+package platformsh_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+func TestFailedActivity(t *testing.T) {
+	ctx := context.Background()
+	client, server, _ := newTestClient(t, testFixture(), platformsh.Config{})
+	server.FailActivities("environment.branch", "Building application\nE: build hook failed")
+
+	op, err := client.CreateEnvironment(ctx, testProjectID, "main", &platformsh.BranchRequest{Name: "broken"})
+	if err != nil {
+		t.Fatalf("CreateEnvironment: %v", err)
+	}
+	_, err = client.WaitForOperation(ctx, testProjectID, op)
+
+	var activityErr *platformsh.ActivityError
+	if !errors.As(err, &activityErr) {
+		t.Fatalf("WaitForOperation: got %v, want an ActivityError", err)
+	}
+	if !strings.Contains(err.Error(), "E: build hook failed") {
+		t.Fatalf("error %q does not include the activity log", err)
+	}
+}
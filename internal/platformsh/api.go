@@ -0,0 +1,42 @@
+package platformsh
+
+import "context"
+
+// ProjectsAPI covers the project operations.
+type ProjectsAPI interface {
+	GetProjects(ctx context.Context) ([]Project, error)
+	GetProject(ctx context.Context, projectID string) (*Project, error)
+}
+
+// EnvironmentsAPI covers the environment operations.
+type EnvironmentsAPI interface {
+	GetEnvironments(ctx context.Context, projectID string) ([]Environment, error)
+	GetEnvironment(ctx context.Context, projectID, environmentID string) (*Environment, error)
+	CreateEnvironment(ctx context.Context, projectID, parentID string, branch *BranchRequest) (*OperationResponse, error)
+	UpdateEnvironment(ctx context.Context, projectID, environmentID string, patch *EnvironmentPatch) (*OperationResponse, error)
+	DeleteEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error)
+	ActivateEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error)
+	DeactivateEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error)
+	PauseEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error)
+	ResumeEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error)
+}
+
+// ActivitiesAPI covers tracking of asynchronous operations.
+type ActivitiesAPI interface {
+	GetActivity(ctx context.Context, projectID, activityID string) (*Activity, error)
+	WaitForActivity(ctx context.Context, projectID, activityID string) (*Activity, error)
+	WaitForOperation(ctx context.Context, projectID string, op *OperationResponse) ([]Activity, error)
+	WaitForEnvironment(ctx context.Context, projectID, environmentID string) (*Environment, error)
+}
+
+// API is everything the provider needs from Platform.sh. Resources and data
+// sources depend on it rather than on Client so that they can be exercised
+// against in-memory implementations.
+type API interface {
+	ProjectsAPI
+	EnvironmentsAPI
+	ActivitiesAPI
+}
+
+// Ensure Client satisfies the API interface.
+var _ API = &Client{}
@@ -0,0 +1,97 @@
+package platformsh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// tokenExpiryDelta is how long before its expiry an access token is
+// replaced, so that requests in flight do not race the expiry.
+const tokenExpiryDelta = time.Minute
+
+// AuthError is returned by API calls when no access token could be obtained
+// for them.
+type AuthError struct {
+	// Source names the credentials that were used.
+	Source string
+	Err    error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("obtaining access token from %s: %v", e.Source, e.Err)
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// tokenSource obtains OAuth2 access tokens from its credentials and caches
+// the result until shortly before it expires. It is safe for concurrent use:
+// callers that need a new token while an exchange is in progress wait for it
+// instead of starting their own.
+type tokenSource struct {
+	restyClient *resty.Client
+	tokenURL    string
+	credentials Credentials
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+func newTokenSource(restyClient *resty.Client, tokenURL string, credentials Credentials) *tokenSource {
+	return &tokenSource{
+		restyClient: restyClient,
+		tokenURL:    tokenURL,
+		credentials: credentials,
+	}
+}
+
+// Token returns a valid access token, obtaining a new one when there is none
+// yet or the current one is about to expire.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.valid() {
+		return ts.accessToken, nil
+	}
+
+	tokenResponse, err := ts.credentials.Token(ctx, ts.restyClient, ts.tokenURL)
+	if err != nil {
+		return "", &AuthError{Source: ts.credentials.Source(), Err: err}
+	}
+
+	ts.accessToken = tokenResponse.AccessToken
+	ts.expiry = time.Time{}
+	if tokenResponse.ExpiresIn > 0 {
+		ts.expiry = time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+	}
+
+	return ts.accessToken, nil
+}
+
+// Invalidate discards token if it is still the cached access token, so that
+// the next call to Token obtains a new one. Passing the rejected
+// token rather than clearing unconditionally keeps concurrent callers that
+// all saw the same 401 from triggering one exchange each.
+func (ts *tokenSource) Invalidate(token string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.accessToken == token {
+		ts.accessToken = ""
+		ts.expiry = time.Time{}
+	}
+}
+
+// valid reports whether the cached access token can still be used. A token
+// without an expiry is used until the API rejects it. Callers must hold mu.
+func (ts *tokenSource) valid() bool {
+	if ts.accessToken == "" {
+		return false
+	}
+	return ts.expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(ts.expiry)
+}
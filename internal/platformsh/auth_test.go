@@ -0,0 +1,37 @@
+package platformsh_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+func TestTokenReexchangedAfterUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	client, server, counter := newTestClient(t, testFixture(), platformsh.Config{})
+
+	if _, err := client.GetProjects(ctx); err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+	server.ExpireTokens()
+	if _, err := client.GetProjects(ctx); err != nil {
+		t.Fatalf("GetProjects after the token expired: %v", err)
+	}
+
+	if got := counter.count(http.MethodPost, "/oauth2/token"); got != 2 {
+		t.Fatalf("token endpoint called %d times, want 2", got)
+	}
+}
+
+func TestInvalidAPIToken(t *testing.T) {
+	client, _, _ := newTestClient(t, testFixture(), platformsh.Config{APIToken: "wrong"})
+
+	_, err := client.GetProjects(context.Background())
+	var authErr *platformsh.AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("GetProjects: got %v, want an AuthError", err)
+	}
+}
@@ -0,0 +1,104 @@
+package platformsh
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultEnvironmentCacheTTL is how long a project's environment list
+// answers GetEnvironment calls before it is fetched again.
+const DefaultEnvironmentCacheTTL = 5 * time.Second
+
+// environmentCache coalesces GetEnvironment calls during a refresh: the
+// first call for a project lists all its environments, and concurrent and
+// subsequent calls within the TTL are answered from that list. Any write to
+// the project drops its entry.
+type environmentCache struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	projects map[string]*environmentCacheEntry
+}
+
+type environmentCacheEntry struct {
+	// done is closed once the list call finished and the fields below are
+	// set.
+	done         chan struct{}
+	environments map[string]Environment
+	err          error
+	fetchedAt    time.Time
+}
+
+func newEnvironmentCache(ttl time.Duration) *environmentCache {
+	return &environmentCache{
+		ttl:      ttl,
+		projects: map[string]*environmentCacheEntry{},
+	}
+}
+
+// get returns the environments of a project, calling list unless a fresh or
+// in-flight result can be reused. The list call is detached from the
+// cancellation of the caller that starts it, since other callers may be
+// waiting for it too; each caller still stops waiting when its own ctx is
+// done.
+func (ec *environmentCache) get(ctx context.Context, projectID string, list func(context.Context) ([]Environment, error)) (map[string]Environment, error) {
+	ec.mu.Lock()
+	entry, ok := ec.projects[projectID]
+	if ok && entry.expired(ec.ttl) {
+		delete(ec.projects, projectID)
+		ok = false
+	}
+	if !ok {
+		entry = &environmentCacheEntry{done: make(chan struct{})}
+		ec.projects[projectID] = entry
+		go ec.fill(context.WithoutCancel(ctx), projectID, entry, list)
+	}
+	ec.mu.Unlock()
+
+	select {
+	case <-entry.done:
+		return entry.environments, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (ec *environmentCache) fill(ctx context.Context, projectID string, entry *environmentCacheEntry, list func(context.Context) ([]Environment, error)) {
+	environments, err := list(ctx)
+
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	if err == nil {
+		entry.environments = make(map[string]Environment, len(environments))
+		for _, env := range environments {
+			entry.environments[env.ID] = env
+		}
+	} else if ec.projects[projectID] == entry {
+		// Do not cache failures.
+		delete(ec.projects, projectID)
+	}
+	entry.err = err
+	entry.fetchedAt = time.Now()
+	close(entry.done)
+}
+
+// invalidate drops the cached environments of a project.
+func (ec *environmentCache) invalidate(projectID string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	delete(ec.projects, projectID)
+}
+
+// expired reports whether a finished entry is older than ttl. Callers must
+// hold the cache lock.
+func (e *environmentCacheEntry) expired(ttl time.Duration) bool {
+	select {
+	case <-e.done:
+		return time.Since(e.fetchedAt) > ttl
+	default:
+		return false
+	}
+}
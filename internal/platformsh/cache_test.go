@@ -0,0 +1,38 @@
+package platformsh_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+func TestCoalescedEnvironmentReads(t *testing.T) {
+	ctx := context.Background()
+	client, _, counter := newTestClient(t, testFixture(), platformsh.Config{EnvironmentCacheTTL: time.Minute})
+
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for _, id := range []string{"main", "staging", "old-feature", "main", "staging"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetEnvironment(ctx, testProjectID, id); err != nil {
+				t.Errorf("GetEnvironment(%q): %v", id, err)
+				failures.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if failures.Load() > 0 {
+		return
+	}
+
+	if got := counter.count(http.MethodGet, "/projects/"+testProjectID+"/environments"); got != 1 {
+		t.Fatalf("environments listed %d times, want 1", got)
+	}
+}
@@ -1,13 +1,96 @@
 package platformsh
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
+const (
+	// DefaultAPIURL is the base URL of the Platform.sh REST API.
+	DefaultAPIURL = "https://api.platform.sh"
+	// DefaultAuthURL is the base URL of the Platform.sh OAuth2 server.
+	DefaultAuthURL = "https://auth.api.platform.sh"
+
+	// DefaultUserAgent is sent when Config does not name the caller.
+	DefaultUserAgent = "terraform-provider-platformsh"
+
+	tokenPath = "/oauth2/token"
+)
+
+// Config holds the settings used to build a Client.
+type Config struct {
+	// Credentials obtain the access tokens sent with every request.
+	Credentials Credentials
+	// APIToken is the Platform.sh API token exchanged for access tokens
+	// when Credentials is nil.
+	APIToken string
+	// APIURL is the base URL of the REST API. Defaults to DefaultAPIURL.
+	APIURL string
+	// AuthURL is the base URL of the OAuth2 server. Defaults to DefaultAuthURL.
+	AuthURL string
+
+	// UserAgent is sent with every request. Defaults to DefaultUserAgent.
+	UserAgent string
+
+	// ProxyURL is the proxy all requests go through. When empty, the
+	// HTTPS_PROXY and NO_PROXY environment variables apply.
+	ProxyURL string
+	// CACertFile and CACertPEM add PEM-encoded CA certificates to the system
+	// pool, for proxies that intercept TLS.
+	CACertFile string
+	CACertPEM  string
+	// InsecureSkipVerify disables TLS certificate verification. Only meant
+	// for local stand-ins of the API.
+	InsecureSkipVerify bool
+	// RequestTimeout bounds each HTTP request. Defaults to
+	// DefaultRequestTimeout.
+	RequestTimeout time.Duration
+
+	// RequestsPerSecond and MaxConcurrentRequests throttle the requests sent
+	// by the client. Default to DefaultRequestsPerSecond and
+	// DefaultMaxConcurrentRequests.
+	RequestsPerSecond     float64
+	MaxConcurrentRequests int
+
+	// MaxRetries is the number of times a failed request is retried. Zero
+	// disables retries.
+	MaxRetries int
+	// RetryWaitMin and RetryWaitMax bound the backoff between retries.
+	// Default to DefaultRetryWaitMin and DefaultRetryWaitMax.
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// ActivityPollInterval is how often WaitForActivity polls. Defaults to
+	// DefaultActivityPollInterval.
+	ActivityPollInterval time.Duration
+
+	// EnvironmentCacheTTL is how long a listed environment may answer
+	// GetEnvironment. Defaults to DefaultEnvironmentCacheTTL; a negative
+	// value disables the cache.
+	EnvironmentCacheTTL time.Duration
+
+	// PageSize is the number of items requested per page of a collection.
+	// Defaults to DefaultPageSize.
+	PageSize int
+	// MaxListItems is the most items a list call may return before it fails.
+	// Defaults to DefaultMaxListItems.
+	MaxListItems int
+}
+
 type Client struct {
 	restyClient *resty.Client
+	tokens      *tokenSource
+	apiURL      string
+	authURL     string
+
+	activityPollInterval time.Duration
+	pageSize             int
+	maxListItems         int
+	environmentCache     *environmentCache
 }
 
 type TokenResponse struct {
@@ -17,9 +100,18 @@ type TokenResponse struct {
 }
 
 type Project struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	OrganizationID string `json:"organization_id"`
+	DefaultBranch  string `json:"default_branch"`
+	Links          Links  `json:"_links,omitempty"`
+}
+
+// Allows reports whether the current user may perform the action behind the
+// given link relation on the project.
+func (p *Project) Allows(rel string) bool {
+	return p.Links.Has(rel)
 }
 
 type Environment struct {
@@ -28,125 +120,333 @@ type Environment struct {
 	Title          string `json:"title"`
 	Type           string `json:"type"`
 	Status         string `json:"status"`
+	Parent         string `json:"parent"`
 	DefaultDomain  string `json:"default_domain"`
 	EnableSMTP     bool   `json:"enable_smtp"`
 	RestrictRobots bool   `json:"restrict_robots"`
 	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+	Links          Links  `json:"_links,omitempty"`
 }
 
-func NewClient(apiToken string) (*Client, error) {
-	client := resty.New()
-	tokenResp, err := client.R().
-		SetBasicAuth("platform-api-user", "").
-		SetFormData(map[string]string{
-			"grant_type": "api_token",
-			"api_token":  apiToken,
-		}).
-		SetResult(&TokenResponse{}).
-		Post("https://auth.api.platform.sh/oauth2/token")
+// Environment statuses reported by the API. An environment is dirty while an
+// activity changes it.
+const (
+	EnvironmentStatusActive   = "active"
+	EnvironmentStatusInactive = "inactive"
+	EnvironmentStatusPaused   = "paused"
+	EnvironmentStatusDirty    = "dirty"
+	EnvironmentStatusDeleting = "deleting"
+)
+
+// Allows reports whether the current user may perform the action behind the
+// given link relation on the environment in its current state.
+func (e *Environment) Allows(rel string) bool {
+	return e.Links.Has(rel)
+}
+
+// BranchRequest describes a new environment branched from an existing one.
+type BranchRequest struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+	// Type defaults to development on the API side.
+	Type string `json:"type,omitempty"`
+	// CloneParent copies the data of the parent into the new environment.
+	CloneParent bool `json:"clone_parent"`
+}
 
+// EnvironmentPatch holds the writable settings of an environment. Nil
+// fields are left unchanged.
+type EnvironmentPatch struct {
+	Title          *string `json:"title,omitempty"`
+	Type           *string `json:"type,omitempty"`
+	Parent         *string `json:"parent,omitempty"`
+	EnableSMTP     *bool   `json:"enable_smtp,omitempty"`
+	RestrictRobots *bool   `json:"restrict_robots,omitempty"`
+}
+
+// NewClient creates a client for the default Platform.sh endpoints.
+func NewClient(ctx context.Context, apiToken string) (*Client, error) {
+	return NewClientFromConfig(ctx, Config{APIToken: apiToken, MaxRetries: DefaultMaxRetries})
+}
+
+// NewClientFromConfig creates a client for the endpoints set in config,
+// falling back to the Platform.sh defaults for any that are empty. It makes
+// no network calls: credentials are exchanged for an access token on the
+// first API request, and failing that, the error is returned by the request.
+func NewClientFromConfig(ctx context.Context, config Config) (*Client, error) {
+	apiURL := strings.TrimRight(config.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	authURL := strings.TrimRight(config.AuthURL, "/")
+	if authURL == "" {
+		authURL = DefaultAuthURL
+	}
+
+	pollInterval := config.ActivityPollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultActivityPollInterval
+	}
+
+	pageSize := config.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	maxListItems := config.MaxListItems
+	if maxListItems <= 0 {
+		maxListItems = DefaultMaxListItems
+	}
+
+	baseTransport, err := newTransport(config)
 	if err != nil {
 		return nil, err
 	}
+	transport := newThrottledTransport(baseTransport, config.RequestsPerSecond, config.MaxConcurrentRequests)
+	timeout := config.RequestTimeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	credentials := config.Credentials
+	if credentials == nil {
+		credentials = &APITokenCredentials{APIToken: config.APIToken}
+	}
 
-	tokenResponse := tokenResp.Result().(*TokenResponse)
-	client.SetAuthToken(tokenResponse.AccessToken)
+	c := &Client{
+		restyClient: resty.New().SetBaseURL(apiURL).SetTransport(transport).SetTimeout(timeout),
+		tokens:      newTokenSource(resty.New().SetTransport(transport).SetTimeout(timeout), authURL+tokenPath, credentials),
+		apiURL:      apiURL,
+		authURL:     authURL,
 
-	return &Client{restyClient: client}, nil
+		activityPollInterval: pollInterval,
+		pageSize:             pageSize,
+		maxListItems:         maxListItems,
+	}
+
+	switch {
+	case config.EnvironmentCacheTTL == 0:
+		c.environmentCache = newEnvironmentCache(DefaultEnvironmentCacheTTL)
+	case config.EnvironmentCacheTTL > 0:
+		c.environmentCache = newEnvironmentCache(config.EnvironmentCacheTTL)
+	}
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	c.restyClient.SetHeader("User-Agent", userAgent)
+	c.tokens.restyClient.SetHeader("User-Agent", userAgent)
+
+	c.restyClient.OnBeforeRequest(c.authenticate)
+	configureRetries(c.restyClient, config)
+	configureRetries(c.tokens.restyClient, config)
+	configureLogging(c.restyClient)
+	configureLogging(c.tokens.restyClient)
+
+	return c, nil
 }
 
-func (c *Client) GetSession() *resty.Client {
-	return c.restyClient
+// authenticate is a resty middleware that attaches a current access token
+// to every API request, including retries.
+func (c *Client) authenticate(_ *resty.Client, req *resty.Request) error {
+	token, err := c.tokens.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.SetAuthToken(token)
+	return nil
+}
+
+// request starts a new API request bound to ctx, so that cancelling ctx
+// aborts the request and any retries still pending. IDs go into the path
+// through SetPathParam(s) only, never by concatenation: resty escapes them
+// with url.PathEscape, which keeps environment IDs such as feature/login in
+// a single path segment.
+func (c *Client) request(ctx context.Context) *resty.Request {
+	return c.restyClient.R().SetContext(withLogging(ctx))
 }
 
-func (c *Client) GetProjects() ([]Project, error) {
-	var projectsResponse struct {
-		Projects []Project `json:"projects"`
+// execute sends req and converts error responses into an APIError. A 401
+// means the access token was revoked or expired early, so it is discarded
+// and the request is sent once more with a fresh one.
+func (c *Client) execute(req *resty.Request, method, path string) (*resty.Response, error) {
+	resp, err := req.Execute(method, path)
+	if err == nil && resp.StatusCode() == http.StatusUnauthorized {
+		c.tokens.Invalidate(req.Token)
+		resp, err = req.Execute(method, path)
 	}
-	_, err := c.restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.restyClient.Token)).
-		SetResult(&projectsResponse).
-		Get("https://api.platform.sh/projects")
 
-	if err != nil {
-		return nil, err
+	return resp, checkResponse(resp, err)
+}
+
+// invalidateEnvironments drops cached environments of a project that was
+// just changed.
+func (c *Client) invalidateEnvironments(projectID string) {
+	if c.environmentCache != nil {
+		c.environmentCache.invalidate(projectID)
 	}
+}
 
-	return projectsResponse.Projects, nil
+// APIURL returns the base URL the client sends API requests to.
+func (c *Client) APIURL() string {
+	return c.apiURL
 }
 
-func (c *Client) GetEnvironments(projectID string) ([]Environment, error) {
-	var environmentsResponse []Environment
-	_, err := c.restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.restyClient.Token)).
-		SetResult(&environmentsResponse).
-		Get(fmt.Sprintf("https://api.platform.sh/projects/%s/environments", projectID))
+// AuthURL returns the base URL the client exchanges tokens with.
+func (c *Client) AuthURL() string {
+	return c.authURL
+}
 
-	if err != nil {
+func (c *Client) GetSession() *resty.Client {
+	return c.restyClient
+}
+
+func (c *Client) GetProjects(ctx context.Context) ([]Project, error) {
+	return listAll[Project](ctx, c, "/projects", nil, "projects")
+}
+
+func (c *Client) GetProject(ctx context.Context, projectID string) (*Project, error) {
+	var project Project
+	req := c.request(ctx).
+		SetPathParam("projectId", projectID).
+		SetResult(&project)
+
+	if _, err := c.execute(req, resty.MethodGet, "/projects/{projectId}"); err != nil {
 		return nil, err
 	}
 
-	return environmentsResponse, nil
+	return &project, nil
 }
 
-func (c *Client) GetEnvironment(projectID, environmentID string) (*Environment, error) {
+func (c *Client) GetEnvironments(ctx context.Context, projectID string) ([]Environment, error) {
+	return listAll[Environment](ctx, c, "/projects/{projectId}/environments", map[string]string{
+		"projectId": projectID,
+	}, "items")
+}
+
+// GetEnvironment returns a single environment. Calls for the same project
+// are answered from one shared list call where possible, which keeps
+// refreshing many environments down to a request per project.
+func (c *Client) GetEnvironment(ctx context.Context, projectID, environmentID string) (*Environment, error) {
+	if c.environmentCache != nil {
+		environments, err := c.environmentCache.get(ctx, projectID, func(ctx context.Context) ([]Environment, error) {
+			return c.GetEnvironments(ctx, projectID)
+		})
+		if env, ok := environments[environmentID]; err == nil && ok {
+			return &env, nil
+		}
+		// Fall through to fetching the environment on its own, which is
+		// authoritative for not-found errors and for environments created
+		// after the list was fetched.
+	}
+
 	var environment Environment
-	_, err := c.restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.restyClient.Token)).
-		SetResult(&environment).
-		Get(fmt.Sprintf("https://api.platform.sh/projects/%s/environments/%s", projectID, environmentID))
+	req := c.request(ctx).
+		SetPathParams(map[string]string{
+			"projectId":     projectID,
+			"environmentId": environmentID,
+		}).
+		SetResult(&environment)
 
-	if err != nil {
+	if _, err := c.execute(req, resty.MethodGet, "/projects/{projectId}/environments/{environmentId}"); err != nil {
 		return nil, err
 	}
 
 	return &environment, nil
 }
 
-type CreateEnvironmentResponse struct {
-	Status string `json:"status"`
-	Code   int    `json:"code"`
+// CreateEnvironment branches a new environment off parentID. The
+// environment exists once the returned operation has finished.
+func (c *Client) CreateEnvironment(ctx context.Context, projectID, parentID string, branch *BranchRequest) (*OperationResponse, error) {
+	var response OperationResponse
+	req := c.request(ctx).
+		SetBody(branch).
+		SetPathParams(map[string]string{
+			"projectId":     projectID,
+			"environmentId": parentID,
+		}).
+		SetResult(&response)
+
+	defer c.invalidateEnvironments(projectID)
+	if _, err := c.execute(req, resty.MethodPost, "/projects/{projectId}/environments/{environmentId}/branch"); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
 }
 
-func (c *Client) CreateEnvironment(projectID, environmentID string, env *Environment) (*CreateEnvironmentResponse, error) {
-	var response CreateEnvironmentResponse
-	_, err := c.restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.restyClient.Token)).
-		SetBody(map[string]interface{}{
-			"title":        env.Title,
-			"name":         env.Name,
-			"clone_parent": true,
-			"type":         "development",
+func (c *Client) UpdateEnvironment(ctx context.Context, projectID, environmentID string, patch *EnvironmentPatch) (*OperationResponse, error) {
+	var response OperationResponse
+	req := c.request(ctx).
+		SetBody(patch).
+		SetPathParams(map[string]string{
+			"projectId":     projectID,
+			"environmentId": environmentID,
 		}).
-		SetResult(&response).
-		Post(fmt.Sprintf("https://api.platform.sh/projects/%s/environments/main/branch", projectID))
+		SetResult(&response)
 
-	if err != nil {
+	defer c.invalidateEnvironments(projectID)
+	if _, err := c.execute(req, resty.MethodPatch, "/projects/{projectId}/environments/{environmentId}"); err != nil {
 		return nil, err
 	}
 
 	return &response, nil
 }
 
-func (c *Client) UpdateEnvironment(projectID, environmentID string, environment *Environment) (*Environment, error) {
-	var updatedEnvironment Environment
-	_, err := c.restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.restyClient.Token)).
-		SetBody(environment).
-		SetResult(&updatedEnvironment).
-		Patch(fmt.Sprintf("https://api.platform.sh/projects/%s/environments/%s", projectID, environmentID))
+func (c *Client) DeleteEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error) {
+	var response OperationResponse
+	req := c.request(ctx).
+		SetPathParams(map[string]string{
+			"projectId":     projectID,
+			"environmentId": environmentID,
+		}).
+		SetResult(&response)
 
-	if err != nil {
+	defer c.invalidateEnvironments(projectID)
+	if _, err := c.execute(req, resty.MethodDelete, "/projects/{projectId}/environments/{environmentId}"); err != nil {
 		return nil, err
 	}
 
-	return &updatedEnvironment, nil
+	return &response, nil
 }
 
-func (c *Client) DeleteEnvironment(projectID, environmentID string) error {
-	_, err := c.restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.restyClient.Token)).
-		Delete(fmt.Sprintf("https://api.platform.sh/projects/%s/environments/%s", projectID, environmentID))
+// ActivateEnvironment deploys an inactive environment.
+func (c *Client) ActivateEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error) {
+	return c.environmentAction(ctx, projectID, environmentID, "activate")
+}
 
-	return err
+// DeactivateEnvironment deletes the services and data of an environment but
+// keeps its branch.
+func (c *Client) DeactivateEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error) {
+	return c.environmentAction(ctx, projectID, environmentID, "deactivate")
+}
+
+// PauseEnvironment stops the services of an active environment.
+func (c *Client) PauseEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error) {
+	return c.environmentAction(ctx, projectID, environmentID, "pause")
+}
+
+// ResumeEnvironment restarts the services of a paused environment.
+func (c *Client) ResumeEnvironment(ctx context.Context, projectID, environmentID string) (*OperationResponse, error) {
+	return c.environmentAction(ctx, projectID, environmentID, "resume")
+}
+
+// environmentAction starts one of the environment actions that take no
+// parameters.
+func (c *Client) environmentAction(ctx context.Context, projectID, environmentID, action string) (*OperationResponse, error) {
+	var response OperationResponse
+	req := c.request(ctx).
+		SetPathParams(map[string]string{
+			"projectId":     projectID,
+			"environmentId": environmentID,
+			"action":        action,
+		}).
+		SetResult(&response)
+
+	defer c.invalidateEnvironments(projectID)
+	if _, err := c.execute(req, resty.MethodPost, "/projects/{projectId}/environments/{environmentId}/{action}"); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
 }
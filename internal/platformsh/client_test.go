@@ -0,0 +1,201 @@
+package platformsh_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh/fake"
+)
+
+const (
+	testAPIToken  = "test-api-token"
+	testProjectID = "project1"
+)
+
+func testFixture() *fake.Fixture {
+	return &fake.Fixture{
+		APIToken: testAPIToken,
+		Projects: []fake.FixtureProject{{
+			ID:    testProjectID,
+			Title: "Project 1",
+			Environments: []fake.FixtureEnvironment{
+				{ID: "main", Type: "production", Status: "active"},
+				{ID: "staging", Type: "staging", Status: "active", Parent: "main"},
+				{ID: "old-feature", Status: "inactive", Parent: "main"},
+			},
+		}},
+	}
+}
+
+// requestCounter serves the fake API and counts the requests it gets per
+// method and unescaped path.
+type requestCounter struct {
+	handler http.Handler
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (rc *requestCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	rc.counts[r.Method+" "+r.URL.Path]++
+	rc.mu.Unlock()
+	rc.handler.ServeHTTP(w, r)
+}
+
+func (rc *requestCounter) count(method, path string) int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.counts[method+" "+path]
+}
+
+// newTestClient starts a fake server for fixture and returns a client for
+// it, along with the server and a counter of the requests it received.
+func newTestClient(t *testing.T, fixture *fake.Fixture, config platformsh.Config, opts ...fake.Option) (*platformsh.Client, *fake.Server, *requestCounter) {
+	t.Helper()
+
+	server := fake.NewServer(fixture, opts...)
+	t.Cleanup(server.Close)
+	counter := &requestCounter{handler: server, counts: map[string]int{}}
+	httpServer := httptest.NewServer(counter)
+	t.Cleanup(httpServer.Close)
+
+	if config.APIToken == "" && config.Credentials == nil {
+		config.APIToken = testAPIToken
+	}
+	config.APIURL = httpServer.URL
+	config.AuthURL = httpServer.URL
+	if config.ActivityPollInterval == 0 {
+		config.ActivityPollInterval = time.Millisecond
+	}
+	if config.RetryWaitMin == 0 {
+		config.RetryWaitMin = time.Millisecond
+		config.RetryWaitMax = 10 * time.Millisecond
+	}
+
+	client, err := platformsh.NewClientFromConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("NewClientFromConfig: %v", err)
+	}
+	return client, server, counter
+}
+
+// waitFor runs an operation and waits for the activities it started.
+func waitFor(t *testing.T, client *platformsh.Client, op *platformsh.OperationResponse, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("starting operation: %v", err)
+	}
+	if _, err := client.WaitForOperation(context.Background(), testProjectID, op); err != nil {
+		t.Fatalf("WaitForOperation: %v", err)
+	}
+}
+
+func assertStatus(t *testing.T, client *platformsh.Client, environmentID, want string) {
+	t.Helper()
+
+	env, err := client.GetEnvironment(context.Background(), testProjectID, environmentID)
+	if err != nil {
+		t.Fatalf("GetEnvironment(%q): %v", environmentID, err)
+	}
+	if env.Status != want {
+		t.Fatalf("environment %q has status %q, want %q", environmentID, env.Status, want)
+	}
+}
+
+func TestEnvironmentLifecycle(t *testing.T) {
+	ctx := context.Background()
+	client, _, _ := newTestClient(t, testFixture(), platformsh.Config{}, fake.WithActivityPolls(2))
+
+	op, err := client.CreateEnvironment(ctx, testProjectID, "staging", &platformsh.BranchRequest{
+		Name:  "feature/login",
+		Title: "Login",
+	})
+	waitFor(t, client, op, err)
+
+	env, err := client.GetEnvironment(ctx, testProjectID, "feature/login")
+	if err != nil {
+		t.Fatalf("GetEnvironment: %v", err)
+	}
+	if env.Status != platformsh.EnvironmentStatusActive || env.Parent != "staging" || env.Title != "Login" || env.Type != "development" {
+		t.Fatalf("unexpected environment after branching: %+v", env)
+	}
+	if !env.Allows(platformsh.LinkDeactivate) || env.Allows(platformsh.LinkDelete) {
+		t.Fatalf("unexpected links on an active environment: %v", env.Links)
+	}
+
+	title := "Sign in"
+	smtp := true
+	op, err = client.UpdateEnvironment(ctx, testProjectID, env.ID, &platformsh.EnvironmentPatch{Title: &title, EnableSMTP: &smtp})
+	waitFor(t, client, op, err)
+	env, err = client.GetEnvironment(ctx, testProjectID, env.ID)
+	if err != nil {
+		t.Fatalf("GetEnvironment: %v", err)
+	}
+	if env.Title != title || !env.EnableSMTP {
+		t.Fatalf("update not applied: %+v", env)
+	}
+
+	op, err = client.PauseEnvironment(ctx, testProjectID, env.ID)
+	waitFor(t, client, op, err)
+	assertStatus(t, client, env.ID, platformsh.EnvironmentStatusPaused)
+
+	op, err = client.ResumeEnvironment(ctx, testProjectID, env.ID)
+	waitFor(t, client, op, err)
+	assertStatus(t, client, env.ID, platformsh.EnvironmentStatusActive)
+
+	op, err = client.DeactivateEnvironment(ctx, testProjectID, env.ID)
+	waitFor(t, client, op, err)
+	assertStatus(t, client, env.ID, platformsh.EnvironmentStatusInactive)
+
+	op, err = client.DeleteEnvironment(ctx, testProjectID, env.ID)
+	waitFor(t, client, op, err)
+	if _, err := client.GetEnvironment(ctx, testProjectID, env.ID); !platformsh.IsNotFound(err) {
+		t.Fatalf("GetEnvironment after delete: got %v, want a 404", err)
+	}
+}
+
+func TestActivateInactiveEnvironment(t *testing.T) {
+	ctx := context.Background()
+	client, _, _ := newTestClient(t, testFixture(), platformsh.Config{})
+
+	op, err := client.ActivateEnvironment(ctx, testProjectID, "old-feature")
+	waitFor(t, client, op, err)
+	assertStatus(t, client, "old-feature", platformsh.EnvironmentStatusActive)
+}
+
+func TestDeleteActiveEnvironmentFails(t *testing.T) {
+	client, _, _ := newTestClient(t, testFixture(), platformsh.Config{})
+
+	_, err := client.DeleteEnvironment(context.Background(), testProjectID, "staging")
+	var apiErr *platformsh.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("DeleteEnvironment on an active environment: got %v, want a 400", err)
+	}
+}
+
+func TestWaitForDirtyEnvironment(t *testing.T) {
+	ctx := context.Background()
+	client, _, _ := newTestClient(t, testFixture(), platformsh.Config{}, fake.WithActivityPolls(3))
+
+	// Start an activity without waiting for it.
+	if _, err := client.PauseEnvironment(ctx, testProjectID, "staging"); err != nil {
+		t.Fatalf("PauseEnvironment: %v", err)
+	}
+	assertStatus(t, client, "staging", platformsh.EnvironmentStatusDirty)
+
+	env, err := client.WaitForEnvironment(ctx, testProjectID, "staging")
+	if err != nil {
+		t.Fatalf("WaitForEnvironment: %v", err)
+	}
+	if env.Status != platformsh.EnvironmentStatusPaused {
+		t.Fatalf("environment settled as %q, want %q", env.Status, platformsh.EnvironmentStatusPaused)
+	}
+}
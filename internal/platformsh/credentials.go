@@ -0,0 +1,226 @@
+package platformsh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// DefaultAPITokenClientID is the OAuth2 client that API tokens are
+	// exchanged with.
+	DefaultAPITokenClientID = "platform-api-user"
+
+	cliSessionPath = ".session/sess-cli-default/sess-cli-default.json"
+)
+
+// ErrNoCredentials is returned by ResolveCredentials when none of the
+// candidates is configured, and by requests made with MissingCredentials.
+var ErrNoCredentials = errors.New("no Platform.sh credentials found")
+
+// Credentials obtain OAuth2 access tokens for the client.
+type Credentials interface {
+	// Source names where the credentials come from, for logging.
+	Source() string
+	// Available reports whether the credentials are configured, without
+	// contacting the API.
+	Available() bool
+	// Token obtains a new access token, using restyClient to talk to the
+	// OAuth2 token endpoint at tokenURL when needed.
+	Token(ctx context.Context, restyClient *resty.Client, tokenURL string) (*TokenResponse, error)
+}
+
+// ResolveCredentials returns the first available credentials, so that one
+// configuration can pick up an explicit token in CI and a CLI login on a
+// laptop.
+func ResolveCredentials(candidates ...Credentials) (Credentials, error) {
+	for _, c := range candidates {
+		if c != nil && c.Available() {
+			return c, nil
+		}
+	}
+	return nil, ErrNoCredentials
+}
+
+// APITokenCredentials exchange a Platform.sh API token for access tokens.
+type APITokenCredentials struct {
+	APIToken string
+	// ClientID defaults to DefaultAPITokenClientID.
+	ClientID string
+}
+
+func (c *APITokenCredentials) Source() string { return "api_token" }
+
+func (c *APITokenCredentials) Available() bool { return c.APIToken != "" }
+
+func (c *APITokenCredentials) Token(ctx context.Context, restyClient *resty.Client, tokenURL string) (*TokenResponse, error) {
+	clientID := c.ClientID
+	if clientID == "" {
+		clientID = DefaultAPITokenClientID
+	}
+	return requestToken(ctx, restyClient, tokenURL, clientID, "", map[string]string{
+		"grant_type": "api_token",
+		"api_token":  c.APIToken,
+	})
+}
+
+// AccessTokenCredentials use a pre-issued access token as is. The token
+// cannot be renewed, so requests fail once it expires.
+type AccessTokenCredentials struct {
+	AccessToken string
+}
+
+func (c *AccessTokenCredentials) Source() string { return "access_token" }
+
+func (c *AccessTokenCredentials) Available() bool { return c.AccessToken != "" }
+
+func (c *AccessTokenCredentials) Token(context.Context, *resty.Client, string) (*TokenResponse, error) {
+	return &TokenResponse{AccessToken: c.AccessToken, TokenType: "bearer"}, nil
+}
+
+// ClientCredentials use the OAuth2 client_credentials grant with a custom
+// client ID and secret.
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+func (c *ClientCredentials) Source() string { return "client_credentials" }
+
+func (c *ClientCredentials) Available() bool { return c.ClientID != "" && c.ClientSecret != "" }
+
+func (c *ClientCredentials) Token(ctx context.Context, restyClient *resty.Client, tokenURL string) (*TokenResponse, error) {
+	return requestToken(ctx, restyClient, tokenURL, c.ClientID, c.ClientSecret, map[string]string{
+		"grant_type": "client_credentials",
+	})
+}
+
+// CLISessionCredentials reuse the access token stored by a logged-in
+// Platform.sh or Upsun CLI. The session file is read again on every renewal,
+// so tokens the CLI refreshes in the meantime are picked up. The refresh
+// token is deliberately not used: redeeming it would sign the CLI out.
+type CLISessionCredentials struct {
+	// Path is the session file. When empty, the default locations of the
+	// Platform.sh and Upsun CLIs are searched.
+	Path string
+}
+
+func (c *CLISessionCredentials) Source() string {
+	if path := c.sessionFile(); path != "" {
+		return "cli_session (" + path + ")"
+	}
+	return "cli_session"
+}
+
+func (c *CLISessionCredentials) Available() bool { return c.sessionFile() != "" }
+
+func (c *CLISessionCredentials) Token(context.Context, *resty.Client, string) (*TokenResponse, error) {
+	path := c.sessionFile()
+	if path == "" {
+		return nil, errors.New("no Platform.sh CLI session file found")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CLI session: %w", err)
+	}
+
+	// The CLI has stored the token with both key styles over time.
+	var session struct {
+		AccessToken      string `json:"accessToken"`
+		AccessTokenSnake string `json:"access_token"`
+		Expires          int64  `json:"expires"`
+		ExpiresSnake     int64  `json:"expires_at"`
+	}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("parsing CLI session %s: %w", path, err)
+	}
+
+	token := session.AccessToken
+	if token == "" {
+		token = session.AccessTokenSnake
+	}
+	expires := session.Expires
+	if expires == 0 {
+		expires = session.ExpiresSnake
+	}
+	if token == "" {
+		return nil, fmt.Errorf("CLI session %s holds no access token; log in with the CLI first", path)
+	}
+
+	resp := &TokenResponse{AccessToken: token, TokenType: "bearer"}
+	if expires > 0 {
+		remaining := time.Until(time.Unix(expires, 0))
+		if remaining <= tokenExpiryDelta {
+			return nil, fmt.Errorf("CLI session %s has expired; run any CLI command to renew it", path)
+		}
+		resp.ExpiresIn = int(remaining / time.Second)
+	}
+	return resp, nil
+}
+
+func (c *CLISessionCredentials) sessionFile() string {
+	if c.Path != "" {
+		if _, err := os.Stat(c.Path); err == nil {
+			return c.Path
+		}
+		return ""
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	for _, dir := range []string{".platformsh", ".upsun-cli"} {
+		path := filepath.Join(home, dir, filepath.FromSlash(cliSessionPath))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// MissingCredentials stand in when no usable credentials are configured.
+// A client built with them still works for everything that needs no API
+// call, and reports Reason from the first request that does.
+type MissingCredentials struct {
+	Reason string
+}
+
+func (c *MissingCredentials) Source() string { return "no credentials" }
+
+func (c *MissingCredentials) Available() bool { return false }
+
+func (c *MissingCredentials) Token(context.Context, *resty.Client, string) (*TokenResponse, error) {
+	if c.Reason == "" {
+		return nil, ErrNoCredentials
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoCredentials, c.Reason)
+}
+
+// requestToken calls the OAuth2 token endpoint.
+func requestToken(ctx context.Context, restyClient *resty.Client, tokenURL, clientID, clientSecret string, form map[string]string) (*TokenResponse, error) {
+	tokenResp, err := restyClient.R().
+		SetContext(ctx).
+		SetBasicAuth(clientID, clientSecret).
+		SetFormData(form).
+		SetResult(&TokenResponse{}).
+		Post(tokenURL)
+
+	if err := checkResponse(tokenResp, err); err != nil {
+		return nil, err
+	}
+
+	tokenResponse := tokenResp.Result().(*TokenResponse)
+	if strings.TrimSpace(tokenResponse.AccessToken) == "" {
+		return nil, errors.New("token endpoint returned no access token")
+	}
+	return tokenResponse, nil
+}
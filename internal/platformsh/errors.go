@@ -0,0 +1,125 @@
+package platformsh
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// APIError is returned when Platform.sh answers a request with a non-2xx
+// status code.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+	// Method and URL identify the request that failed.
+	Method string `json:"-"`
+	URL    string `json:"-"`
+
+	// Code is the error code reported in the response body, usually the
+	// HTTP status code again.
+	Code int `json:"code"`
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+	// Detail holds any additional error detail, either a string or an object.
+	Detail json.RawMessage `json:"detail,omitempty"`
+
+	// OAuthError and OAuthErrorDescription are set by the auth server, which
+	// reports errors in the OAuth2 format instead.
+	OAuthError            string `json:"error,omitempty"`
+	OAuthErrorDescription string `json:"error_description,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+
+	message := e.Message
+	if message == "" {
+		message = e.OAuthErrorDescription
+	}
+	if message == "" {
+		message = e.OAuthError
+	}
+	if message != "" {
+		b.WriteString(": " + message)
+	}
+	if detail := e.detailString(); detail != "" {
+		b.WriteString(" (" + detail + ")")
+	}
+
+	return b.String()
+}
+
+// detailString renders Detail for display, unquoting plain strings.
+func (e *APIError) detailString() string {
+	if len(e.Detail) == 0 {
+		return ""
+	}
+
+	var s string
+	if err := json.Unmarshal(e.Detail, &s); err == nil {
+		return s
+	}
+
+	detail := string(e.Detail)
+	if detail == "null" || detail == "{}" || detail == "[]" {
+		return ""
+	}
+	return detail
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsForbidden reports whether err is an APIError with status 403.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsUnauthorized reports whether err is an APIError with status 401.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsConflict reports whether err is an APIError with status 409.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// checkResponse converts a non-2xx response into an APIError. Transport
+// errors are returned unchanged.
+func checkResponse(resp *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !resp.IsError() {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode(),
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL,
+	}
+	// The body is not always JSON (e.g. errors from a load balancer), in
+	// which case the status code alone has to do.
+	if jsonErr := json.Unmarshal(resp.Body(), apiErr); jsonErr != nil {
+		apiErr.Message = strings.TrimSpace(string(resp.Body()))
+		if len(apiErr.Message) > 200 {
+			apiErr.Message = apiErr.Message[:200] + "..."
+		}
+	}
+
+	return apiErr
+}
@@ -0,0 +1,138 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type activity struct {
+	id           string
+	typ          string
+	project      string
+	environments []string
+	description  string
+	state        string
+	result       string
+	log          []string
+	polls        int
+	createdAt    string
+	completedAt  string
+
+	// onComplete applies the state change the activity stands for.
+	onComplete func(success bool)
+}
+
+// startActivity records a new pending activity. It completes once it has
+// been polled activityPolls times, or immediately when activityPolls is zero.
+func (s *Server) startActivity(p *project, activityType string, environments []string, description string, onComplete func(success bool)) *activity {
+	a := &activity{
+		id:           fmt.Sprintf("activity%d", s.newID()),
+		typ:          activityType,
+		project:      p.id,
+		environments: environments,
+		description:  description,
+		state:        "pending",
+		log:          []string{"Started " + description},
+		createdAt:    timestamp(),
+		onComplete:   onComplete,
+	}
+	s.activities[a.id] = a
+
+	if s.activityPolls <= 0 {
+		s.completeActivity(a)
+	}
+	return a
+}
+
+func (s *Server) completeActivity(a *activity) {
+	a.state = "complete"
+	a.result = "success"
+	if failureLog, ok := s.failTypes[a.typ]; ok {
+		a.result = "failure"
+		a.log = append(a.log, strings.Split(failureLog, "\n")...)
+	} else {
+		a.log = append(a.log, "Done")
+	}
+	a.completedAt = timestamp()
+
+	if a.onComplete != nil {
+		a.onComplete(a.result == "success")
+	}
+}
+
+func (a *activity) toJSON() map[string]interface{} {
+	percent := 0
+	if a.state == "complete" {
+		percent = 100
+	}
+	var completedAt interface{}
+	if a.completedAt != "" {
+		completedAt = a.completedAt
+	}
+	return map[string]interface{}{
+		"id":                 a.id,
+		"type":               a.typ,
+		"project":            a.project,
+		"environments":       a.environments,
+		"description":        a.description,
+		"state":              a.state,
+		"result":             a.result,
+		"completion_percent": percent,
+		"log":                strings.Join(a.log, "\n"),
+		"created_at":         a.createdAt,
+		"completed_at":       completedAt,
+	}
+}
+
+func (s *Server) routeActivities(w http.ResponseWriter, r *http.Request, p *project, rest []string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if len(rest) == 0 {
+		items := []interface{}{}
+		for _, a := range s.activities {
+			if a.project == p.id {
+				items = append(items, a.toJSON())
+			}
+		}
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+
+	a, ok := s.activities[rest[0]]
+	if !ok || a.project != p.id || len(rest) > 1 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Activity %q not found", rest[0]))
+		return
+	}
+
+	if a.state != "complete" {
+		a.polls++
+		a.state = "in_progress"
+		if a.polls >= s.activityPolls {
+			s.completeActivity(a)
+		}
+	}
+	writeJSON(w, http.StatusOK, a.toJSON())
+}
+
+// handleEnvironmentActivities lists the activities of one environment,
+// optionally filtered by one or more state parameters.
+func (s *Server) handleEnvironmentActivities(w http.ResponseWriter, r *http.Request, e *environment) {
+	states := r.URL.Query()["state"]
+
+	items := []interface{}{}
+	for _, a := range s.activities {
+		if a.project != e.project.id || !slices.Contains(a.environments, e.id) {
+			continue
+		}
+		if len(states) > 0 && !slices.Contains(states, a.state) {
+			continue
+		}
+		items = append(items, a.toJSON())
+	}
+	writeJSON(w, http.StatusOK, items)
+}
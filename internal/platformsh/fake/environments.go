@@ -0,0 +1,374 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Environment statuses.
+const (
+	statusActive   = "active"
+	statusInactive = "inactive"
+	statusPaused   = "paused"
+	statusDirty    = "dirty"
+	statusDeleting = "deleting"
+)
+
+type environment struct {
+	project        *project
+	id             string
+	title          string
+	typ            string
+	status         string
+	parent         string
+	enableSMTP     bool
+	restrictRobots bool
+	createdAt      string
+	updatedAt      string
+
+	variables map[string]*variable
+	varOrder  []string
+}
+
+func newEnvironmentFromFixture(p *project, fe FixtureEnvironment) *environment {
+	e := &environment{
+		project:        p,
+		id:             fe.ID,
+		title:          fe.Title,
+		typ:            fe.Type,
+		status:         fe.Status,
+		parent:         fe.Parent,
+		enableSMTP:     fe.EnableSMTP,
+		restrictRobots: fe.RestrictRobots,
+		createdAt:      timestamp(),
+		variables:      map[string]*variable{},
+	}
+	e.updatedAt = e.createdAt
+	if e.title == "" {
+		e.title = e.id
+	}
+	if e.typ == "" {
+		e.typ = "development"
+		if e.id == p.defaultBranch {
+			e.typ = "production"
+		}
+	}
+	if e.status == "" {
+		e.status = statusActive
+	}
+	for _, fv := range fe.Variables {
+		e.setVariable(&variable{name: fv.Name, value: fv.Value, isSensitive: fv.IsSensitive, createdAt: e.createdAt, updatedAt: e.createdAt})
+	}
+	return e
+}
+
+func (e *environment) path() string {
+	return e.project.path() + "/environments/" + url.PathEscape(e.id)
+}
+
+// machineName turns the ID into something usable in a host name.
+func (e *environment) machineName() string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, e.id)
+}
+
+func (e *environment) defaultDomain() string {
+	return fmt.Sprintf("%s-%s.fake.platformsh.site", e.machineName(), e.project.id)
+}
+
+// links returns the actions allowed in the current status, mirroring the
+// rules the real API applies.
+func (e *environment) links() map[string]interface{} {
+	links := map[string]interface{}{
+		"self": link(e.path()),
+	}
+	if e.status == statusDirty || e.status == statusDeleting {
+		return links
+	}
+
+	links["#edit"] = link(e.path())
+	production := e.typ == "production"
+	switch e.status {
+	case statusActive:
+		links["#branch"] = link(e.path() + "/branch")
+		links["#redeploy"] = link(e.path() + "/redeploy")
+		if e.parent != "" {
+			links["#merge"] = link(e.path() + "/merge")
+			links["#synchronize"] = link(e.path() + "/synchronize")
+		}
+		if !production {
+			links["#deactivate"] = link(e.path() + "/deactivate")
+			links["#pause"] = link(e.path() + "/pause")
+		}
+	case statusPaused:
+		links["#resume"] = link(e.path() + "/resume")
+		if !production {
+			links["#deactivate"] = link(e.path() + "/deactivate")
+		}
+	case statusInactive:
+		links["#activate"] = link(e.path() + "/activate")
+		if !production {
+			links["#delete"] = link(e.path())
+		}
+	}
+	return links
+}
+
+func (e *environment) allows(rel string) bool {
+	_, ok := e.links()[rel]
+	return ok
+}
+
+func (e *environment) toJSON() map[string]interface{} {
+	var parent interface{}
+	if e.parent != "" {
+		parent = e.parent
+	}
+	return map[string]interface{}{
+		"id":              e.id,
+		"name":            e.id,
+		"machine_name":    e.machineName(),
+		"title":           e.title,
+		"type":            e.typ,
+		"status":          e.status,
+		"parent":          parent,
+		"project":         e.project.id,
+		"default_domain":  e.defaultDomain(),
+		"enable_smtp":     e.enableSMTP,
+		"restrict_robots": e.restrictRobots,
+		"is_dirty":        e.status == statusDirty,
+		"created_at":      e.createdAt,
+		"updated_at":      e.updatedAt,
+		"_links":          e.links(),
+	}
+}
+
+func (s *Server) routeEnvironments(w http.ResponseWriter, r *http.Request, p *project, rest []string) {
+	if len(rest) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		items := []interface{}{}
+		for _, id := range p.envOrder {
+			items = append(items, p.environments[id].toJSON())
+		}
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+
+	e, ok := p.environments[rest[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Environment %q not found", rest[0]))
+		return
+	}
+
+	switch {
+	case len(rest) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, e.toJSON())
+	case len(rest) == 1 && r.Method == http.MethodPatch:
+		s.handleUpdateEnvironment(w, r, e)
+	case len(rest) == 1 && r.Method == http.MethodDelete:
+		s.handleDeleteEnvironment(w, e)
+	case len(rest) >= 2 && rest[1] == "variables":
+		s.routeVariables(w, r, e, rest[2:])
+	case len(rest) == 2 && rest[1] == "activities" && r.Method == http.MethodGet:
+		s.handleEnvironmentActivities(w, r, e)
+	case len(rest) == 2 && r.Method == http.MethodPost:
+		s.handleEnvironmentAction(w, r, e, rest[1])
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) handleUpdateEnvironment(w http.ResponseWriter, r *http.Request, e *environment) {
+	if !e.allows("#edit") {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q cannot be edited while it is %s", e.id, e.status))
+		return
+	}
+
+	var body struct {
+		Title          *string `json:"title"`
+		Type           *string `json:"type"`
+		Parent         *string `json:"parent"`
+		EnableSMTP     *bool   `json:"enable_smtp"`
+		RestrictRobots *bool   `json:"restrict_robots"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if body.Parent != nil && *body.Parent != "" {
+		if _, ok := e.project.environments[*body.Parent]; !ok || *body.Parent == e.id {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q cannot be the parent of %q", *body.Parent, e.id))
+			return
+		}
+	}
+
+	redeploy := false
+	if body.Title != nil {
+		e.title = *body.Title
+	}
+	if body.Type != nil && *body.Type != "" {
+		e.typ = *body.Type
+	}
+	if body.Parent != nil && *body.Parent != "" {
+		e.parent = *body.Parent
+	}
+	if body.EnableSMTP != nil && *body.EnableSMTP != e.enableSMTP {
+		e.enableSMTP = *body.EnableSMTP
+		redeploy = true
+	}
+	if body.RestrictRobots != nil && *body.RestrictRobots != e.restrictRobots {
+		e.restrictRobots = *body.RestrictRobots
+		redeploy = true
+	}
+	e.updatedAt = timestamp()
+
+	var activities []*activity
+	if redeploy && e.status == statusActive {
+		activities = append(activities, s.startTransition(e, "environment.update", statusActive, statusActive))
+	}
+	writeOperation(w, http.StatusOK, e.toJSON(), activities...)
+}
+
+func (s *Server) handleDeleteEnvironment(w http.ResponseWriter, e *environment) {
+	if !e.allows("#delete") {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q cannot be deleted while it is %s", e.id, e.status))
+		return
+	}
+
+	e.status = statusDeleting
+	a := s.startActivity(e.project, "environment.delete", []string{e.id}, fmt.Sprintf("Deleted environment %s", e.id), func(success bool) {
+		if success {
+			e.project.removeEnvironment(e.id)
+		} else {
+			e.status = statusInactive
+		}
+	})
+	writeOperation(w, http.StatusAccepted, nil, a)
+}
+
+// actionRules maps each environment action to the link that must be present,
+// the activity type it starts and the status it ends in.
+var actionRules = map[string]struct {
+	rel          string
+	activityType string
+	target       string
+}{
+	"activate":   {"#activate", "environment.activate", statusActive},
+	"deactivate": {"#deactivate", "environment.deactivate", statusInactive},
+	"pause":      {"#pause", "environment.pause", statusPaused},
+	"resume":     {"#resume", "environment.resume", statusActive},
+	"redeploy":   {"#redeploy", "environment.redeploy", statusActive},
+}
+
+func (s *Server) handleEnvironmentAction(w http.ResponseWriter, r *http.Request, e *environment, action string) {
+	if action == "branch" {
+		s.handleBranch(w, r, e)
+		return
+	}
+
+	rule, ok := actionRules[action]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if !e.allows(rule.rel) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q cannot %s while it is %s", e.id, action, e.status))
+		return
+	}
+
+	previous := e.status
+	a := s.startTransition(e, rule.activityType, rule.target, previous)
+	writeOperation(w, http.StatusAccepted, nil, a)
+}
+
+func (s *Server) handleBranch(w http.ResponseWriter, r *http.Request, parent *environment) {
+	if !parent.allows("#branch") {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q cannot be branched while it is %s", parent.id, parent.status))
+		return
+	}
+
+	var body struct {
+		Name        string `json:"name"`
+		Title       string `json:"title"`
+		Type        string `json:"type"`
+		CloneParent *bool  `json:"clone_parent"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.Name == "" {
+		writeError(w, http.StatusBadRequest, "The name of the new environment is required")
+		return
+	}
+	if _, exists := parent.project.environments[body.Name]; exists {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Environment %q already exists", body.Name))
+		return
+	}
+
+	e := newEnvironmentFromFixture(parent.project, FixtureEnvironment{
+		ID:     body.Name,
+		Title:  body.Title,
+		Type:   body.Type,
+		Parent: parent.id,
+	})
+	if e.typ == "production" {
+		e.typ = "development"
+	}
+	if body.CloneParent == nil || *body.CloneParent {
+		for _, name := range parent.varOrder {
+			v := *parent.variables[name]
+			e.setVariable(&v)
+		}
+	}
+	parent.project.addEnvironment(e)
+
+	a := s.startTransition(e, "environment.branch", statusActive, statusInactive)
+	writeOperation(w, http.StatusCreated, nil, a)
+}
+
+// startTransition marks e dirty and starts an activity that moves it to
+// target on success, or back to fallback on failure.
+func (s *Server) startTransition(e *environment, activityType, target, fallback string) *activity {
+	e.status = statusDirty
+	e.updatedAt = timestamp()
+	return s.startActivity(e.project, activityType, []string{e.id}, fmt.Sprintf("%s on environment %s", activityType, e.id), func(success bool) {
+		if success {
+			e.status = target
+		} else {
+			e.status = fallback
+		}
+		e.updatedAt = timestamp()
+	})
+}
+
+func writeOperation(w http.ResponseWriter, status int, entity map[string]interface{}, activities ...*activity) {
+	embedded := map[string]interface{}{}
+	if entity != nil {
+		embedded["entity"] = entity
+	}
+	items := []interface{}{}
+	for _, a := range activities {
+		items = append(items, a.toJSON())
+	}
+	embedded["activities"] = items
+
+	writeJSON(w, status, map[string]interface{}{
+		"status":    "success",
+		"code":      status,
+		"_embedded": embedded,
+	})
+}
@@ -0,0 +1,61 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Fixture is the initial state of a fake server.
+type Fixture struct {
+	// APIToken is the only API token the token endpoint accepts. Any token
+	// is accepted when it is empty.
+	APIToken string           `json:"api_token"`
+	Projects []FixtureProject `json:"projects"`
+}
+
+// FixtureProject seeds a project and its environments.
+type FixtureProject struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	OrganizationID string `json:"organization_id"`
+	// DefaultBranch is the production environment created for the project
+	// when Environments does not list it. Defaults to "main".
+	DefaultBranch string               `json:"default_branch"`
+	Environments  []FixtureEnvironment `json:"environments"`
+}
+
+// FixtureEnvironment seeds an environment.
+type FixtureEnvironment struct {
+	ID             string            `json:"id"`
+	Title          string            `json:"title"`
+	Type           string            `json:"type"`
+	Status         string            `json:"status"`
+	Parent         string            `json:"parent"`
+	EnableSMTP     bool              `json:"enable_smtp"`
+	RestrictRobots bool              `json:"restrict_robots"`
+	Variables      []FixtureVariable `json:"variables"`
+}
+
+// FixtureVariable seeds an environment variable.
+type FixtureVariable struct {
+	Name        string `json:"name"`
+	Value       string `json:"value"`
+	IsSensitive bool   `json:"is_sensitive"`
+}
+
+// LoadFixture reads a fixture from a JSON file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", path, err)
+	}
+
+	return &fixture, nil
+}
@@ -0,0 +1,147 @@
+package fake
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const defaultPageSize = 100
+
+type project struct {
+	id             string
+	title          string
+	description    string
+	organizationID string
+	defaultBranch  string
+	createdAt      string
+
+	environments map[string]*environment
+	envOrder     []string
+}
+
+func (s *Server) addProject(fp FixtureProject) {
+	p := &project{
+		id:             fp.ID,
+		title:          fp.Title,
+		description:    fp.Description,
+		organizationID: fp.OrganizationID,
+		defaultBranch:  fp.DefaultBranch,
+		createdAt:      timestamp(),
+		environments:   map[string]*environment{},
+	}
+	if p.defaultBranch == "" {
+		p.defaultBranch = "main"
+	}
+
+	for _, fe := range fp.Environments {
+		p.addEnvironment(newEnvironmentFromFixture(p, fe))
+	}
+	if _, ok := p.environments[p.defaultBranch]; !ok {
+		p.addEnvironment(newEnvironmentFromFixture(p, FixtureEnvironment{
+			ID:   p.defaultBranch,
+			Type: "production",
+		}))
+	}
+
+	s.projects[p.id] = p
+	s.projectOrder = append(s.projectOrder, p.id)
+}
+
+func (p *project) addEnvironment(e *environment) {
+	if _, ok := p.environments[e.id]; !ok {
+		p.envOrder = append(p.envOrder, e.id)
+	}
+	p.environments[e.id] = e
+}
+
+func (p *project) removeEnvironment(id string) {
+	delete(p.environments, id)
+	for i, envID := range p.envOrder {
+		if envID == id {
+			p.envOrder = append(p.envOrder[:i], p.envOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func (p *project) path() string {
+	return "/projects/" + url.PathEscape(p.id)
+}
+
+func (p *project) toJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"id":              p.id,
+		"title":           p.title,
+		"description":     p.description,
+		"organization_id": p.organizationID,
+		"default_branch":  p.defaultBranch,
+		"created_at":      p.createdAt,
+		"_links": map[string]interface{}{
+			"self":  link(p.path()),
+			"#edit": link(p.path()),
+		},
+	}
+}
+
+// handleProjects lists projects a page at a time, using the ID of the last
+// project on a page as the cursor for the next one.
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	size, err := strconv.Atoi(query.Get("page[size]"))
+	if err != nil || size <= 0 {
+		size = defaultPageSize
+	}
+
+	start := 0
+	if after := query.Get("page[after]"); after != "" {
+		for i, id := range s.projectOrder {
+			if id == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := start + size
+	if end > len(s.projectOrder) {
+		end = len(s.projectOrder)
+	}
+
+	items := []interface{}{}
+	for _, id := range s.projectOrder[start:end] {
+		items = append(items, s.projects[id].toJSON())
+	}
+
+	links := map[string]interface{}{
+		"self": link(r.URL.RequestURI()),
+	}
+	if end < len(s.projectOrder) {
+		next := url.Values{}
+		next.Set("page[size]", strconv.Itoa(size))
+		next.Set("page[after]", s.projectOrder[end-1])
+		links["next"] = link("/projects?" + next.Encode())
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"projects": items,
+		"count":    len(s.projectOrder),
+		"_links":   links,
+	})
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request, p *project) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, p.toJSON())
+}
+
+func link(href string) map[string]string {
+	return map[string]string{"href": href}
+}
@@ -0,0 +1,308 @@
+// Package fake implements an in-memory stand-in for the Platform.sh API.
+//
+// The server emulates the OAuth2 token endpoint and the project,
+// environment, activity and variable endpoints the provider uses, keeping
+// enough state to walk environments through their real lifecycle: changes
+// start activities, the environment is dirty while they run, and it settles
+// into its new status once they complete. Failures can be injected to
+// exercise error handling.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failure makes the server answer matching requests with an error instead
+// of handling them.
+type Failure struct {
+	// Method and Path select the requests to fail. Empty values match any
+	// method or path. Path is compared against the unescaped request path.
+	Method string
+	Path   string
+	// Status is the HTTP status code to answer with.
+	Status int
+	// RetryAfter, when set, is sent as the Retry-After header.
+	RetryAfter string
+	// Times is how many requests to fail. Zero fails every matching request.
+	Times int
+}
+
+// Server is a fake Platform.sh API and OAuth2 server.
+type Server struct {
+	// URL is the base URL of the server, usable as both the API and the
+	// auth URL.
+	URL string
+
+	httpServer *httptest.Server
+
+	// activityPolls is how many times an activity must be polled before it
+	// completes.
+	activityPolls int
+
+	mu           sync.Mutex
+	apiToken     string
+	tokens       map[string]bool
+	projects     map[string]*project
+	projectOrder []string
+	activities   map[string]*activity
+	failures     []*Failure
+	failTypes    map[string]string
+	nextID       int
+}
+
+// Option adjusts a server before it starts serving.
+type Option func(*Server)
+
+// WithActivityPolls sets how many times an activity must be polled before
+// it completes. Zero completes activities immediately. Defaults to 1.
+func WithActivityPolls(n int) Option {
+	return func(s *Server) {
+		s.activityPolls = n
+	}
+}
+
+// NewServer starts a fake server on a random loopback port.
+func NewServer(fixture *Fixture, opts ...Option) *Server {
+	s := newServer(fixture, opts)
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Listen starts a fake server on addr, for use outside of tests.
+func Listen(addr string, fixture *Fixture, opts ...Option) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newServer(fixture, opts)
+	s.httpServer = httptest.NewUnstartedServer(s)
+	s.httpServer.Listener.Close()
+	s.httpServer.Listener = listener
+	s.httpServer.Start()
+	s.URL = s.httpServer.URL
+	return s, nil
+}
+
+func newServer(fixture *Fixture, opts []Option) *Server {
+	if fixture == nil {
+		fixture = &Fixture{}
+	}
+
+	s := &Server{
+		activityPolls: 1,
+		apiToken:      fixture.APIToken,
+		tokens:        map[string]bool{},
+		projects:      map[string]*project{},
+		activities:    map[string]*activity{},
+		failTypes:     map[string]string{},
+	}
+	for _, p := range fixture.Projects {
+		s.addProject(p)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// InjectFailure registers a failure for matching requests.
+func (s *Server) InjectFailure(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, &f)
+}
+
+// FailActivities makes every later activity of the given type, such as
+// "environment.branch", finish with a failure and log.
+func (s *Server) FailActivities(activityType, log string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failTypes[activityType] = log
+}
+
+// ExpireTokens revokes every access token issued so far, so that clients
+// have to exchange their API token again.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = map[string]bool{}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments, err := pathSegments(r.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.injectFailure(w, r.Method, "/"+strings.Join(segments, "/")) {
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == "/oauth2/token" {
+		s.handleToken(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "Invalid or expired access token")
+		return
+	}
+
+	s.route(w, r, segments)
+}
+
+func (s *Server) injectFailure(w http.ResponseWriter, method, path string) bool {
+	for i, f := range s.failures {
+		if (f.Method != "" && f.Method != method) || (f.Path != "" && f.Path != path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		if f.RetryAfter != "" {
+			w.Header().Set("Retry-After", f.RetryAfter)
+		}
+		writeError(w, f.Status, "Injected failure")
+		return true
+	}
+	return false
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, "invalid_request", err.Error())
+		return
+	}
+	if r.PostForm.Get("grant_type") != "api_token" {
+		writeOAuthError(w, "unsupported_grant_type", "Only the api_token grant is supported")
+		return
+	}
+	if token := r.PostForm.Get("api_token"); token == "" || (s.apiToken != "" && token != s.apiToken) {
+		writeOAuthError(w, "invalid_grant", "Invalid API token")
+		return
+	}
+
+	token := fmt.Sprintf("fake-access-token-%d", s.newID())
+	s.tokens[token] = true
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"expires_in":   900,
+		"token_type":   "bearer",
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.tokens[token]
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) == 0 || segments[0] != "projects" {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if len(segments) == 1 {
+		s.handleProjects(w, r)
+		return
+	}
+
+	p, ok := s.projects[segments[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Project %q not found", segments[1]))
+		return
+	}
+
+	rest := segments[2:]
+	switch {
+	case len(rest) == 0:
+		s.handleProject(w, r, p)
+	case rest[0] == "environments":
+		s.routeEnvironments(w, r, p, rest[1:])
+	case rest[0] == "activities":
+		s.routeActivities(w, r, p, rest[1:])
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+// pathSegments splits the escaped request path, so that IDs containing
+// slashes stay in one segment.
+func pathSegments(u *url.URL) ([]string, error) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(u.EscapedPath(), "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, unescaped)
+	}
+	return segments, nil
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"status":  "error",
+		"code":    status,
+		"message": message,
+		"title":   http.StatusText(status),
+	})
+}
+
+func writeOAuthError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
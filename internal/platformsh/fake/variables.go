@@ -0,0 +1,142 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type variable struct {
+	name        string
+	value       string
+	isSensitive bool
+	createdAt   string
+	updatedAt   string
+}
+
+func (e *environment) setVariable(v *variable) {
+	if _, ok := e.variables[v.name]; !ok {
+		e.varOrder = append(e.varOrder, v.name)
+	}
+	e.variables[v.name] = v
+}
+
+func (e *environment) removeVariable(name string) {
+	delete(e.variables, name)
+	for i, n := range e.varOrder {
+		if n == name {
+			e.varOrder = append(e.varOrder[:i], e.varOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// toJSON renders the variable the way the API does: sensitive values are
+// never returned.
+func (v *variable) toJSON() map[string]interface{} {
+	data := map[string]interface{}{
+		"id":           v.name,
+		"name":         v.name,
+		"is_sensitive": v.isSensitive,
+		"created_at":   v.createdAt,
+		"updated_at":   v.updatedAt,
+	}
+	if !v.isSensitive {
+		data["value"] = v.value
+	}
+	return data
+}
+
+// routeVariables handles the environment variable endpoints. Changing a
+// variable redeploys an active environment, like the real API does.
+func (s *Server) routeVariables(w http.ResponseWriter, r *http.Request, e *environment, rest []string) {
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			items := []interface{}{}
+			for _, name := range e.varOrder {
+				items = append(items, e.variables[name].toJSON())
+			}
+			writeJSON(w, http.StatusOK, items)
+		case http.MethodPost:
+			s.handleSetVariable(w, r, e, nil)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	v, ok := e.variables[rest[0]]
+	if !ok || len(rest) > 1 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Variable %q not found", rest[0]))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, v.toJSON())
+	case http.MethodPatch:
+		s.handleSetVariable(w, r, e, v)
+	case http.MethodDelete:
+		if e.status == statusDirty || e.status == statusDeleting {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q is %s", e.id, e.status))
+			return
+		}
+		e.removeVariable(v.name)
+		writeOperation(w, http.StatusOK, nil, s.redeployForVariable(e, "environment.variable.delete")...)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (s *Server) handleSetVariable(w http.ResponseWriter, r *http.Request, e *environment, existing *variable) {
+	if e.status == statusDirty || e.status == statusDeleting {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Environment %q is %s", e.id, e.status))
+		return
+	}
+
+	var body struct {
+		Name        string  `json:"name"`
+		Value       *string `json:"value"`
+		IsSensitive *bool   `json:"is_sensitive"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	activityType := "environment.variable.update"
+	v := existing
+	if v == nil {
+		if body.Name == "" {
+			writeError(w, http.StatusBadRequest, "The variable name is required")
+			return
+		}
+		if _, exists := e.variables[body.Name]; exists {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Variable %q already exists", body.Name))
+			return
+		}
+		activityType = "environment.variable.create"
+		v = &variable{name: body.Name, createdAt: timestamp()}
+	}
+	if body.Value != nil {
+		v.value = *body.Value
+	}
+	if body.IsSensitive != nil {
+		v.isSensitive = *body.IsSensitive
+	}
+	v.updatedAt = timestamp()
+	e.setVariable(v)
+
+	status := http.StatusOK
+	if existing == nil {
+		status = http.StatusCreated
+	}
+	writeOperation(w, status, v.toJSON(), s.redeployForVariable(e, activityType)...)
+}
+
+func (s *Server) redeployForVariable(e *environment, activityType string) []*activity {
+	if e.status != statusActive {
+		return nil
+	}
+	return []*activity{s.startTransition(e, activityType, statusActive, statusActive)}
+}
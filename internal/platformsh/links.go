@@ -0,0 +1,40 @@
+package platformsh
+
+// Relations of the HAL links that the API includes in _links. An action
+// link is only present when the current user may perform the action on the
+// object in its current state.
+const (
+	LinkSelf        = "self"
+	LinkEdit        = "#edit"
+	LinkDelete      = "#delete"
+	LinkActivate    = "#activate"
+	LinkDeactivate  = "#deactivate"
+	LinkPause       = "#pause"
+	LinkResume      = "#resume"
+	LinkBranch      = "#branch"
+	LinkMerge       = "#merge"
+	LinkSynchronize = "#synchronize"
+	LinkBackup      = "#backup"
+	LinkRedeploy    = "#redeploy"
+)
+
+// Link is a single HAL link.
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+// Links holds the HAL links of an object, keyed by relation.
+type Links map[string]Link
+
+// Has reports whether the link with the given relation is present.
+func (l Links) Has(rel string) bool {
+	_, ok := l[rel]
+	return ok
+}
+
+// Href returns the target of the link with the given relation, or an empty
+// string when it is not present.
+func (l Links) Href(rel string) string {
+	return l[rel].Href
+}
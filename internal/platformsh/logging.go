@@ -0,0 +1,163 @@
+package platformsh
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// logSubsystem is the tflog subsystem for HTTP traffic. Its level can be
+	// set separately with TF_LOG_PROVIDER_PLATFORMSH_API.
+	logSubsystem = "platformsh_api"
+
+	redacted = "REDACTED"
+)
+
+// requestIDHeader carries the ID Platform.sh support needs to trace a
+// request.
+const requestIDHeader = "X-Request-Id"
+
+// sensitiveKeys are JSON object keys and form fields whose values are never
+// logged.
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"api_token":     true,
+	"client_secret": true,
+	"password":      true,
+	"key":           true,
+	"private_key":   true,
+}
+
+// bearerTokenRegexp matches credentials that end up in free text, such as
+// an Authorization header echoed in an error page.
+var bearerTokenRegexp = regexp.MustCompile(`(?i)(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+
+// withLogging returns ctx with the HTTP logging subsystem set up.
+func withLogging(ctx context.Context) context.Context {
+	return tflog.NewSubsystem(ctx, logSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_PLATFORMSH_API"))
+}
+
+// configureLogging hooks request and response logging into client, and
+// silences resty's own logger, which writes to stderr.
+func configureLogging(client *resty.Client) {
+	client.
+		SetLogger(discardLogger{}).
+		SetDisableWarn(true).
+		OnAfterResponse(logResponse).
+		OnError(logError)
+}
+
+func logResponse(_ *resty.Client, resp *resty.Response) error {
+	ctx := resp.Request.Context()
+	fields := requestFields(resp.Request)
+	fields["http_status"] = resp.StatusCode()
+	fields["latency_ms"] = resp.Time().Milliseconds()
+	if requestID := resp.Header().Get(requestIDHeader); requestID != "" {
+		fields["request_id"] = requestID
+	}
+
+	tflog.SubsystemDebug(ctx, logSubsystem, "Platform.sh API response", fields)
+	tflog.SubsystemTrace(ctx, logSubsystem, "Platform.sh API exchange", map[string]interface{}{
+		"http_method":   resp.Request.Method,
+		"http_url":      resp.Request.URL,
+		"request_body":  requestBody(resp.Request),
+		"response_body": redactBody(resp.Body()),
+	})
+
+	return nil
+}
+
+func logError(req *resty.Request, err error) {
+	fields := requestFields(req)
+	fields["error"] = bearerTokenRegexp.ReplaceAllString(err.Error(), "$1 "+redacted)
+
+	tflog.SubsystemDebug(req.Context(), logSubsystem, "Platform.sh API request failed", fields)
+}
+
+func requestFields(req *resty.Request) map[string]interface{} {
+	return map[string]interface{}{
+		"http_method": req.Method,
+		"http_url":    req.URL,
+		"attempt":     req.Attempt,
+	}
+}
+
+// requestBody renders the body of req with secrets redacted.
+func requestBody(req *resty.Request) string {
+	if len(req.FormData) > 0 {
+		form := url.Values{}
+		for key, values := range req.FormData {
+			if sensitiveKeys[key] {
+				values = []string{redacted}
+			}
+			form[key] = values
+		}
+		return form.Encode()
+	}
+	if req.Body == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(req.Body)
+	if err != nil {
+		return ""
+	}
+	return redactBody(data)
+}
+
+// redactBody masks secrets in a JSON body. Bodies that are not JSON are only
+// scrubbed of bearer and basic credentials.
+func redactBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return bearerTokenRegexp.ReplaceAllString(string(body), "$1 "+redacted)
+	}
+
+	data, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// redactValue walks a decoded JSON value and masks sensitive keys, as well
+// as the value of any object flagged with is_sensitive, such as a sensitive
+// variable.
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		sensitive, _ := v["is_sensitive"].(bool)
+		for key, value := range v {
+			if sensitiveKeys[strings.ToLower(key)] || (sensitive && key == "value") {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+		return v
+	case string:
+		return bearerTokenRegexp.ReplaceAllString(v, "$1 "+redacted)
+	}
+	return v
+}
+
+// discardLogger drops resty's log output; requests are logged through tflog
+// instead.
+type discardLogger struct{}
+
+func (discardLogger) Errorf(string, ...interface{}) {}
+func (discardLogger) Warnf(string, ...interface{})  {}
+func (discardLogger) Debugf(string, ...interface{}) {}
@@ -0,0 +1,112 @@
+package platformsh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// DefaultPageSize is the number of items requested per page.
+	DefaultPageSize = 100
+	// DefaultMaxListItems is the most items a single list call returns
+	// before giving up, as a guard against runaway pagination.
+	DefaultMaxListItems = 10000
+
+	linkNext = "next"
+)
+
+// listPage is one page of a collection. The API returns collections either
+// as a bare array or as an object holding the items under itemsKey next to
+// _links, which points at the following page when there is one.
+type listPage[T any] struct {
+	itemsKey string
+
+	Items []T
+	Links Links
+}
+
+func (p *listPage[T]) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, &p.Items)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if items, ok := raw[p.itemsKey]; ok {
+		if err := json.Unmarshal(items, &p.Items); err != nil {
+			return err
+		}
+	}
+	if links, ok := raw["_links"]; ok {
+		if err := json.Unmarshal(links, &p.Links); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listAll fetches every page of the collection at path, following the next
+// links (which carry the cursor) until there are none left.
+func listAll[T any](ctx context.Context, c *Client, path string, pathParams map[string]string, itemsKey string) ([]T, error) {
+	var items []T
+	seen := map[string]bool{}
+
+	req := c.request(ctx).
+		SetPathParams(pathParams).
+		SetQueryParam("page[size]", strconv.Itoa(c.pageSize))
+	pageURL := path
+
+	for {
+		page := listPage[T]{itemsKey: itemsKey}
+		if _, err := c.execute(req.SetResult(&page), resty.MethodGet, pageURL); err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		if len(items) > c.maxListItems {
+			return nil, fmt.Errorf("listing %s: more than %d items, which exceeds the configured limit", path, c.maxListItems)
+		}
+
+		next := page.Links.Href(linkNext)
+		if next == "" || seen[next] {
+			return items, nil
+		}
+		seen[next] = true
+
+		// The next link already holds the page size and cursor.
+		var err error
+		if pageURL, err = c.nextPageURL(next); err != nil {
+			return nil, fmt.Errorf("listing %s: %w", path, err)
+		}
+		req = c.request(ctx)
+	}
+}
+
+// nextPageURL checks that a next link points back into the API before it is
+// followed, since every request carries the bearer token. Relative links are
+// resolved against the API URL by resty; absolute ones must start with it.
+func (c *Client) nextPageURL(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page link %q: %w", href, err)
+	}
+	if !u.IsAbs() && u.Host == "" {
+		return href, nil
+	}
+
+	rest, ok := strings.CutPrefix(href, c.apiURL)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?")) {
+		return "", fmt.Errorf("next page link %q is outside the API at %s", href, c.apiURL)
+	}
+	return href, nil
+}
@@ -0,0 +1,75 @@
+package platformsh_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh/fake"
+)
+
+func TestProjectPagination(t *testing.T) {
+	fixture := &fake.Fixture{APIToken: testAPIToken}
+	for i := range 5 {
+		fixture.Projects = append(fixture.Projects, fake.FixtureProject{ID: fmt.Sprintf("project%d", i)})
+	}
+	client, _, counter := newTestClient(t, fixture, platformsh.Config{PageSize: 2})
+
+	projects, err := client.GetProjects(context.Background())
+	if err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+	if len(projects) != 5 {
+		t.Fatalf("got %d projects, want 5", len(projects))
+	}
+	if got := counter.count(http.MethodGet, "/projects"); got != 3 {
+		t.Fatalf("fetched %d pages, want 3", got)
+	}
+
+	limited, _, _ := newTestClient(t, fixture, platformsh.Config{PageSize: 2, MaxListItems: 3})
+	if _, err := limited.GetProjects(context.Background()); err == nil {
+		t.Fatal("GetProjects above MaxListItems: got no error")
+	}
+}
+
+func TestNextLinkToAnotherHost(t *testing.T) {
+	var leaked atomic.Bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(true)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"projects": []}`)
+	}))
+	t.Cleanup(other.Close)
+
+	server := fake.NewServer(testFixture())
+	t.Cleanup(server.Close)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects" {
+			server.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"projects": [], "_links": {"next": {"href": %q}}}`, other.URL+"/projects?page[after]=x")
+	}))
+	t.Cleanup(api.Close)
+
+	client, err := platformsh.NewClientFromConfig(context.Background(), platformsh.Config{
+		APIToken: testAPIToken,
+		APIURL:   api.URL,
+		AuthURL:  api.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig: %v", err)
+	}
+
+	if _, err := client.GetProjects(context.Background()); err == nil {
+		t.Fatal("GetProjects: got no error for a next link to another host")
+	}
+	if leaked.Load() {
+		t.Fatal("the client followed a next link to another host")
+	}
+}
@@ -0,0 +1,88 @@
+package platformsh
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRequestsPerSecond is the sustained request rate of a client.
+	DefaultRequestsPerSecond = 10
+	// DefaultMaxConcurrentRequests is the most requests a client has in
+	// flight at once.
+	DefaultMaxConcurrentRequests = 8
+)
+
+// throttledTransport paces requests with a token bucket and caps how many
+// are in flight. A single instance sits under both the API and the auth
+// client, so every resource and data source configured by one provider
+// shares the same budget.
+type throttledTransport struct {
+	base      http.RoundTripper
+	limiter   *rate.Limiter
+	semaphore chan struct{}
+}
+
+func newThrottledTransport(base http.RoundTripper, requestsPerSecond float64, maxConcurrent int) *throttledTransport {
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = DefaultRequestsPerSecond
+	}
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrentRequests
+	}
+
+	// Allow a burst of one second's worth of requests, but at least one.
+	burst := int(requestsPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &throttledTransport{
+		base:      base,
+		limiter:   rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+		semaphore: make(chan struct{}, maxConcurrent),
+	}
+}
+
+// RoundTrip waits for a free slot and a token before sending req. Both
+// waits give up when the request context is done. The slot is held until
+// the response body is closed.
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	select {
+	case t.semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := sync.OnceFunc(func() { <-t.semaphore })
+
+	if err := t.limiter.Wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody frees a concurrency slot once the response is consumed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,103 @@
+package platformsh
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a failed request is retried
+	// when the provider does not say otherwise.
+	DefaultMaxRetries = 3
+	// DefaultRetryWaitMin is the initial backoff between retries.
+	DefaultRetryWaitMin = time.Second
+	// DefaultRetryWaitMax caps the backoff between retries, including waits
+	// requested through Retry-After.
+	DefaultRetryWaitMax = 30 * time.Second
+)
+
+// configureRetries sets up resty's exponential backoff with jitter for the
+// failures Platform.sh expects clients to retry.
+func configureRetries(client *resty.Client, config Config) {
+	waitMin := config.RetryWaitMin
+	if waitMin <= 0 {
+		waitMin = DefaultRetryWaitMin
+	}
+	waitMax := config.RetryWaitMax
+	if waitMax <= 0 {
+		waitMax = DefaultRetryWaitMax
+	}
+	if waitMax < waitMin {
+		waitMax = waitMin
+	}
+
+	client.
+		SetRetryCount(config.MaxRetries).
+		SetRetryWaitTime(waitMin).
+		SetRetryMaxWaitTime(waitMax).
+		SetRetryAfter(retryAfter).
+		AddRetryCondition(shouldRetry)
+}
+
+// shouldRetry decides whether a request is worth sending again. Idempotent
+// requests are retried on transport errors, 429 and 5xx responses. POST and
+// PATCH requests may already have taken effect in those cases, so they are
+// only retried when the API provably did not process them: the connection
+// could not be established, or the request was rate limited.
+func shouldRetry(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+
+	if err != nil {
+		if isIdempotent(resp.Request.Method) {
+			return true
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+
+	status := resp.StatusCode()
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	if !isIdempotent(resp.Request.Method) {
+		return false
+	}
+	return status >= http.StatusInternalServerError && status != http.StatusNotImplemented
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter honors the Retry-After header of 429 and 503 responses. It
+// returns zero, meaning "use the default backoff", when there is none.
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if resp == nil || resp.RawResponse == nil {
+		return 0, nil
+	}
+
+	header := resp.Header().Get("Retry-After")
+	if header == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if date, err := http.ParseTime(header); err == nil && time.Until(date) > 0 {
+		return time.Until(date), nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,31 @@
+package platformsh_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh/fake"
+)
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	client, server, counter := newTestClient(t, testFixture(), platformsh.Config{MaxRetries: 3})
+	path := "/projects/" + testProjectID + "/environments/staging"
+
+	server.InjectFailure(fake.Failure{Method: http.MethodGet, Path: path, Status: http.StatusServiceUnavailable, Times: 2})
+	if _, err := client.GetEnvironment(ctx, testProjectID, "staging"); err != nil {
+		t.Fatalf("GetEnvironment: %v", err)
+	}
+
+	// Writes are not retried on server errors, as they may have been applied.
+	server.InjectFailure(fake.Failure{Method: http.MethodPatch, Path: path, Status: http.StatusBadGateway, Times: 1})
+	title := "Staging"
+	if _, err := client.UpdateEnvironment(ctx, testProjectID, "staging", &platformsh.EnvironmentPatch{Title: &title}); err == nil {
+		t.Fatal("UpdateEnvironment: got no error, want the injected 502")
+	}
+	if got := counter.count(http.MethodPatch, path); got != 1 {
+		t.Fatalf("PATCH sent %d times, want 1", got)
+	}
+}
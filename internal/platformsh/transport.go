@@ -0,0 +1,60 @@
+package platformsh
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DefaultRequestTimeout bounds a single HTTP request, retries excluded.
+const DefaultRequestTimeout = 60 * time.Second
+
+// newTransport builds the HTTP transport shared by the API and auth clients
+// from the proxy and TLS settings in config.
+func newTransport(config Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if config.CACertFile == "" && config.CACertPEM == "" && !config.InsecureSkipVerify {
+		return transport, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", config.CACertFile)
+		}
+	}
+	if config.CACertPEM != "" {
+		if !pool.AppendCertsFromPEM([]byte(config.CACertPEM)) {
+			return nil, errors.New("no PEM certificates found in the inline CA certificates")
+		}
+	}
+
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            pool,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	return transport, nil
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+// credentialsUnknown reports whether any credential attribute depends on a
+// value that is only known after apply.
+func (m *platformshProviderModel) credentialsUnknown() bool {
+	return m.APIToken.IsUnknown() ||
+		m.AccessToken.IsUnknown() ||
+		m.ClientID.IsUnknown() ||
+		m.ClientSecret.IsUnknown() ||
+		m.CLISessionFile.IsUnknown() ||
+		m.UseCLISession.IsUnknown()
+}
+
+// Origins of the credentials picked by resolveCredentials, for logging.
+const (
+	credentialsFromConfig     = "provider configuration"
+	credentialsFromEnv        = "environment variables"
+	credentialsFromCLISession = "CLI session"
+)
+
+// resolveCredentials picks the credentials to authenticate with and reports
+// where they came from. Credentials set in the provider configuration win
+// over environment variables, so that a stale variable in CI cannot
+// override an explicit attribute; the session of a logged-in CLI comes
+// last. Within each pass the precedence is: a pre-issued access token, a
+// custom OAuth2 client, an API token.
+func (m *platformshProviderModel) resolveCredentials() (platformsh.Credentials, string, error) {
+	configured, err := platformsh.ResolveCredentials(
+		&platformsh.AccessTokenCredentials{
+			AccessToken: m.AccessToken.ValueString(),
+		},
+		// A configured client may still keep its secret in the environment.
+		&platformsh.ClientCredentials{
+			ClientID:     m.ClientID.ValueString(),
+			ClientSecret: stringValueOrEnv(m.ClientSecret, "PLATFORMSH_CLIENT_SECRET"),
+		},
+		&platformsh.APITokenCredentials{
+			APIToken: m.APIToken.ValueString(),
+		},
+	)
+	if err == nil {
+		return configured, credentialsFromConfig, nil
+	}
+
+	fromEnv, err := platformsh.ResolveCredentials(
+		&platformsh.AccessTokenCredentials{
+			AccessToken: lookupEnv("PLATFORMSH_ACCESS_TOKEN"),
+		},
+		&platformsh.ClientCredentials{
+			ClientID:     stringValueOrEnv(m.ClientID, "PLATFORMSH_CLIENT_ID"),
+			ClientSecret: stringValueOrEnv(m.ClientSecret, "PLATFORMSH_CLIENT_SECRET"),
+		},
+		&platformsh.APITokenCredentials{
+			APIToken: lookupEnv("PLATFORMSH_CLI_TOKEN", "UPSUN_CLI_TOKEN"),
+		},
+	)
+	if err == nil {
+		return fromEnv, credentialsFromEnv, nil
+	}
+
+	if !m.UseCLISession.IsNull() && !m.UseCLISession.ValueBool() {
+		return nil, "", platformsh.ErrNoCredentials
+	}
+	session, err := platformsh.ResolveCredentials(&platformsh.CLISessionCredentials{
+		Path: stringValueOrEnv(m.CLISessionFile, "PLATFORMSH_CLI_SESSION_FILE"),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return session, credentialsFromCLISession, nil
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+// credentialEnvVars are cleared before each case, so that the environment
+// of whoever runs the tests does not leak in.
+var credentialEnvVars = []string{
+	"PLATFORMSH_ACCESS_TOKEN",
+	"PLATFORMSH_CLIENT_ID",
+	"PLATFORMSH_CLIENT_SECRET",
+	"PLATFORMSH_CLI_TOKEN",
+	"UPSUN_CLI_TOKEN",
+	"PLATFORMSH_CLI_SESSION_FILE",
+}
+
+func TestResolveCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		config platformshProviderModel
+		env    map[string]string
+		// defaultSession creates a CLI session in the default location.
+		defaultSession bool
+
+		want       platformsh.Credentials
+		wantOrigin string
+		wantErr    error
+	}{
+		{
+			name:       "api_token",
+			config:     platformshProviderModel{APIToken: types.StringValue("config-token")},
+			want:       &platformsh.APITokenCredentials{APIToken: "config-token"},
+			wantOrigin: credentialsFromConfig,
+		},
+		{
+			name: "configured access_token before api_token",
+			config: platformshProviderModel{
+				APIToken:    types.StringValue("config-token"),
+				AccessToken: types.StringValue("config-access-token"),
+			},
+			want:       &platformsh.AccessTokenCredentials{AccessToken: "config-access-token"},
+			wantOrigin: credentialsFromConfig,
+		},
+		{
+			name:       "configured api_token before environment access token",
+			config:     platformshProviderModel{APIToken: types.StringValue("config-token")},
+			env:        map[string]string{"PLATFORMSH_ACCESS_TOKEN": "env-access-token"},
+			want:       &platformsh.APITokenCredentials{APIToken: "config-token"},
+			wantOrigin: credentialsFromConfig,
+		},
+		{
+			name:   "configured client_id with the secret from the environment",
+			config: platformshProviderModel{ClientID: types.StringValue("config-client")},
+			env: map[string]string{
+				"PLATFORMSH_CLIENT_SECRET": "env-secret",
+				"PLATFORMSH_CLI_TOKEN":     "env-token",
+			},
+			want:       &platformsh.ClientCredentials{ClientID: "config-client", ClientSecret: "env-secret"},
+			wantOrigin: credentialsFromConfig,
+		},
+		{
+			name: "configured client_secret wins over the environment",
+			config: platformshProviderModel{
+				ClientID:     types.StringValue("config-client"),
+				ClientSecret: types.StringValue("config-secret"),
+			},
+			env:        map[string]string{"PLATFORMSH_CLIENT_SECRET": "env-secret"},
+			want:       &platformsh.ClientCredentials{ClientID: "config-client", ClientSecret: "config-secret"},
+			wantOrigin: credentialsFromConfig,
+		},
+		{
+			name:       "environment API token",
+			env:        map[string]string{"UPSUN_CLI_TOKEN": "env-token"},
+			want:       &platformsh.APITokenCredentials{APIToken: "env-token"},
+			wantOrigin: credentialsFromEnv,
+		},
+		{
+			name: "environment client before environment API token",
+			env: map[string]string{
+				"PLATFORMSH_CLIENT_ID":     "env-client",
+				"PLATFORMSH_CLIENT_SECRET": "env-secret",
+				"PLATFORMSH_CLI_TOKEN":     "env-token",
+			},
+			want:       &platformsh.ClientCredentials{ClientID: "env-client", ClientSecret: "env-secret"},
+			wantOrigin: credentialsFromEnv,
+		},
+		{
+			name:           "environment before CLI session",
+			env:            map[string]string{"PLATFORMSH_CLI_TOKEN": "env-token"},
+			defaultSession: true,
+			want:           &platformsh.APITokenCredentials{APIToken: "env-token"},
+			wantOrigin:     credentialsFromEnv,
+		},
+		{
+			name:           "CLI session in the default location",
+			defaultSession: true,
+			wantOrigin:     credentialsFromCLISession,
+		},
+		{
+			name:           "use_cli_session = false",
+			config:         platformshProviderModel{UseCLISession: types.BoolValue(false)},
+			defaultSession: true,
+			wantErr:        platformsh.ErrNoCredentials,
+		},
+		{
+			name:           "missing cli_session_file does not fall back to the default location",
+			config:         platformshProviderModel{CLISessionFile: types.StringValue("does-not-exist.json")},
+			defaultSession: true,
+			wantErr:        platformsh.ErrNoCredentials,
+		},
+		{
+			name:    "nothing configured",
+			wantErr: platformsh.ErrNoCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			for _, envVar := range credentialEnvVars {
+				t.Setenv(envVar, "")
+			}
+			for envVar, value := range tt.env {
+				t.Setenv(envVar, value)
+			}
+
+			var sessionFile string
+			if tt.defaultSession {
+				sessionFile = filepath.Join(home, ".platformsh", ".session", "sess-cli-default", "sess-cli-default.json")
+				if err := os.MkdirAll(filepath.Dir(sessionFile), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(sessionFile, []byte(`{"accessToken": "session-token"}`), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, origin, err := tt.config.resolveCredentials()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveCredentials() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveCredentials() error = %v", err)
+			}
+
+			if origin != tt.wantOrigin {
+				t.Errorf("origin = %q, want %q", origin, tt.wantOrigin)
+			}
+			want := tt.want
+			if want == nil {
+				want = &platformsh.CLISessionCredentials{}
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(want) {
+				t.Fatalf("resolveCredentials() = %T, want %T", got, want)
+			}
+			if session, ok := got.(*platformsh.CLISessionCredentials); ok {
+				if session.Source() != "cli_session ("+sessionFile+")" {
+					t.Errorf("session source = %q, want the file at %s", session.Source(), sessionFile)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("resolveCredentials() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
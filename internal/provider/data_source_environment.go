@@ -5,6 +5,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
 )
@@ -21,18 +22,22 @@ func NewProjectDataSource() datasource.DataSource {
 
 // ProjectDataSource defines the data source implementation.
 type ProjectDataSource struct {
-	client *platformsh.Client
+	client platformsh.API
+	// defaultOrganizationID is the provider-level organization_id.
+	defaultOrganizationID types.String
 }
 
 // ProjectDataSourceModel describes the data source data model.
 type ProjectDataSourceModel struct {
-	Projects []ProjectModel `tfsdk:"projects"`
+	OrganizationID types.String   `tfsdk:"organization_id"`
+	Projects       []ProjectModel `tfsdk:"projects"`
 }
 
 type ProjectModel struct {
-	ID          types.String `tfsdk:"id"`
-	Title       types.String `tfsdk:"title"`
-	Description types.String `tfsdk:"description"`
+	ID             types.String `tfsdk:"id"`
+	Title          types.String `tfsdk:"title"`
+	Description    types.String `tfsdk:"description"`
+	OrganizationID types.String `tfsdk:"organization_id"`
 }
 
 func (d *ProjectDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -43,6 +48,11 @@ func (d *ProjectDataSource) Schema(ctx context.Context, req datasource.SchemaReq
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Fetches the list of projects available in Platform.sh",
 		Attributes: map[string]schema.Attribute{
+			"organization_id": schema.StringAttribute{
+				MarkdownDescription: "Only list the projects of this organization. Defaults to the provider `organization_id`; all projects are listed when neither is set",
+				Optional:            true,
+				Computed:            true,
+			},
 			"projects": schema.ListNestedAttribute{
 				MarkdownDescription: "List of projects",
 				Computed:            true,
@@ -60,6 +70,10 @@ func (d *ProjectDataSource) Schema(ctx context.Context, req datasource.SchemaReq
 							MarkdownDescription: "Description of the project",
 							Computed:            true,
 						},
+						"organization_id": schema.StringAttribute{
+							MarkdownDescription: "ID of the organization owning the project",
+							Computed:            true,
+						},
 					},
 				},
 			},
@@ -68,43 +82,48 @@ func (d *ProjectDataSource) Schema(ctx context.Context, req datasource.SchemaReq
 }
 
 func (d *ProjectDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
-	// Prevent panic if the provider has not been configured.
-	if req.ProviderData == nil {
+	data := configureProviderData(&resp.Diagnostics, req.ProviderData, "Data Source")
+	if data == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*platformsh.Client)
+	d.client = data.client
+	d.defaultOrganizationID = data.organizationID
+}
 
-	if !ok {
-		resp.Diagnostics.AddError(
-			"Unexpected Data Source Configure Type",
-			"Expected *platformsh.Client",
-		)
+func (d *ProjectDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		addUnconfiguredError(&resp.Diagnostics)
 		return
 	}
 
-	d.client = client
-}
-
-func (d *ProjectDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var data ProjectDataSourceModel
 
+	// Read the configuration
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.OrganizationID = withDefault(data.OrganizationID, d.defaultOrganizationID)
+
 	// Fetch the projects
-	projects, err := d.client.GetProjects()
+	projects, err := d.client.GetProjects(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Client Error",
-			"Unable to read projects, got error: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, "read projects", err)
 		return
 	}
 
 	// Map the projects to the Terraform data model
+	data.Projects = make([]ProjectModel, 0, len(projects))
 	for _, project := range projects {
+		if isSet(data.OrganizationID) && project.OrganizationID != data.OrganizationID.ValueString() {
+			continue
+		}
 		data.Projects = append(data.Projects, ProjectModel{
-			ID:          types.StringValue(project.ID),
-			Title:       types.StringValue(project.Title),
-			Description: types.StringValue(project.Description),
+			ID:             types.StringValue(project.ID),
+			Title:          types.StringValue(project.Title),
+			Description:    types.StringValue(project.Description),
+			OrganizationID: types.StringValue(project.OrganizationID),
 		})
 	}
 
@@ -120,7 +139,9 @@ func NewEnvironmentDataSource() datasource.DataSource {
 
 // EnvironmentDataSource defines the data source implementation.
 type EnvironmentDataSource struct {
-	client *platformsh.Client
+	client platformsh.API
+	// defaultProjectID is the provider-level project_id.
+	defaultProjectID types.String
 }
 
 // EnvironmentDataSourceModel describes the data source data model.
@@ -143,8 +164,9 @@ func (d *EnvironmentDataSource) Schema(ctx context.Context, req datasource.Schem
 		MarkdownDescription: "Fetches the list of environments for a given project in Platform.sh",
 		Attributes: map[string]schema.Attribute{
 			"project_id": schema.StringAttribute{
-				MarkdownDescription: "ID of the project",
-				Required:            true,
+				MarkdownDescription: "ID of the project. Defaults to the provider `project_id`",
+				Optional:            true,
+				Computed:            true,
 			},
 			"environments": schema.ListNestedAttribute{
 				MarkdownDescription: "List of environments",
@@ -167,25 +189,21 @@ func (d *EnvironmentDataSource) Schema(ctx context.Context, req datasource.Schem
 }
 
 func (d *EnvironmentDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
-	// Prevent panic if the provider has not been configured.
-	if req.ProviderData == nil {
+	data := configureProviderData(&resp.Diagnostics, req.ProviderData, "Data Source")
+	if data == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*platformsh.Client)
+	d.client = data.client
+	d.defaultProjectID = data.projectID
+}
 
-	if !ok {
-		resp.Diagnostics.AddError(
-			"Unexpected Data Source Configure Type",
-			"Expected *platformsh.Client",
-		)
+func (d *EnvironmentDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		addUnconfiguredError(&resp.Diagnostics)
 		return
 	}
 
-	d.client = client
-}
-
-func (d *EnvironmentDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var config EnvironmentDataSourceModel
 
 	// Read the configuration
@@ -194,17 +212,21 @@ func (d *EnvironmentDataSource) Read(ctx context.Context, req datasource.ReadReq
 		return
 	}
 
+	config.ProjectID = withDefault(config.ProjectID, d.defaultProjectID)
+	if config.ProjectID.IsNull() {
+		addMissingProjectError(&resp.Diagnostics, path.Root("project_id"))
+		return
+	}
+
 	// Fetch the environments
-	environments, err := d.client.GetEnvironments(config.ProjectID.ValueString())
+	environments, err := d.client.GetEnvironments(ctx, config.ProjectID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Client Error",
-			"Unable to read environments, got error: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, "read environments", err)
 		return
 	}
 
 	// Map the environments to the Terraform data model
+	config.Environments = make([]EnvironmentModel, 0, len(environments))
 	for _, environment := range environments {
 		config.Environments = append(config.Environments, EnvironmentModel{
 			ID:        types.StringValue(environment.ID),
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+// addClientError records a failed Platform.sh API call, using the status
+// code of the response to pick a summary that tells the user where to look.
+func addClientError(diags *diag.Diagnostics, action string, err error) {
+	summary := "Client Error"
+
+	var apiErr *platformsh.APIError
+	var authErr *platformsh.AuthError
+	var activityErr *platformsh.ActivityError
+	switch {
+	case errors.Is(err, context.Canceled):
+		diags.AddError("Operation Cancelled", "Unable to "+action+": the request was cancelled before Platform.sh responded.")
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		diags.AddError("Operation Timed Out", "Unable to "+action+": the deadline was reached before Platform.sh responded. "+
+			"The operation may still complete on the Platform.sh side; refresh before retrying.")
+		return
+	case errors.Is(err, platformsh.ErrNoCredentials):
+		if errors.As(err, &authErr) {
+			err = authErr.Err
+		}
+		diags.AddError("Missing Platform.sh Credentials", "Unable to "+action+": "+err.Error()+".")
+		return
+	case errors.As(err, &authErr):
+		summary = "Authentication Failed"
+	case errors.As(err, &activityErr):
+		summary = "Platform.sh Activity Failed"
+	case errors.As(err, &apiErr):
+		switch apiErr.StatusCode {
+		case http.StatusUnauthorized:
+			summary = "Authentication Failed"
+		case http.StatusForbidden:
+			summary = "Permission Denied"
+		case http.StatusNotFound:
+			summary = "Not Found"
+		default:
+			summary = "Platform.sh API Error"
+		}
+	}
+
+	diags.AddError(summary, "Unable to "+action+", got error: "+err.Error())
+}
+
+// addUnconfiguredError explains why an operation cannot run without a
+// client, which is the case when Terraform has not configured the provider.
+func addUnconfiguredError(diags *diag.Diagnostics) {
+	diags.AddError(
+		"Provider Not Configured",
+		"The Platform.sh provider has not been configured yet. "+
+			"This is a bug in Terraform or the provider; please report it.",
+	)
+}
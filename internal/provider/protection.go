@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+// environmentTypeProduction is the type of a project's production
+// environment.
+const environmentTypeProduction = "production"
+
+// environmentProtection guards environments against being destroyed by
+// Terraform, whether by deleting or by replacing them.
+type environmentProtection struct {
+	// patterns are glob patterns for environment names and IDs; see
+	// globMatch.
+	patterns []string
+	// patternsUnknown is set while protected_environments depends on values
+	// that are not known yet, in which case every environment is protected.
+	patternsUnknown        bool
+	allowProductionDestroy bool
+}
+
+// newEnvironmentProtection reads the protection settings of the provider
+// configuration and validates the patterns.
+func newEnvironmentProtection(ctx context.Context, diags *diag.Diagnostics, patterns types.List, allowProductionDestroy types.Bool) environmentProtection {
+	protection := environmentProtection{
+		patternsUnknown:        patterns.IsUnknown(),
+		allowProductionDestroy: allowProductionDestroy.ValueBool(),
+	}
+
+	if isSet(patterns) {
+		diags.Append(patterns.ElementsAs(ctx, &protection.patterns, false)...)
+	}
+	for _, pattern := range protection.patterns {
+		if _, err := globMatch(pattern, ""); err != nil {
+			diags.AddError(
+				"Invalid Protected Environment Pattern",
+				fmt.Sprintf("protected_environments contains %q, which is not a valid pattern: %s.", pattern, err),
+			)
+		}
+	}
+
+	return protection
+}
+
+// check reports whether env may be destroyed, and otherwise explains why
+// not. action describes the destruction, such as "delete" or "replace".
+func (p environmentProtection) check(diags *diag.Diagnostics, action string, env *platformsh.Environment) bool {
+	switch {
+	case p.patternsUnknown:
+		diags.AddError(
+			"Environment Is Protected",
+			fmt.Sprintf("Refusing to %s environment %q: protected_environments is not known yet, so every environment is treated as protected.", action, env.ID),
+		)
+		return false
+	case env.Type == environmentTypeProduction && !p.allowProductionDestroy:
+		diags.AddError(
+			"Environment Is Protected",
+			fmt.Sprintf("Refusing to %s environment %q because it is a production environment. "+
+				"Set allow_production_destroy = true in the provider configuration to allow this.", action, env.ID),
+		)
+		return false
+	}
+
+	if pattern, ok := p.match(env); ok {
+		diags.AddError(
+			"Environment Is Protected",
+			fmt.Sprintf("Refusing to %s environment %q because it matches the protected_environments pattern %q. "+
+				"Remove the pattern from the provider configuration to allow this.", action, env.ID, pattern),
+		)
+		return false
+	}
+
+	return true
+}
+
+// match returns the first pattern matching the name or ID of env.
+func (p environmentProtection) match(env *platformsh.Environment) (string, bool) {
+	for _, pattern := range p.patterns {
+		for _, candidate := range []string{env.ID, env.Name} {
+			if candidate == "" {
+				continue
+			}
+			if ok, _ := globMatch(pattern, candidate); ok {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// slashStandIn replaces / before matching. It never occurs in environment
+// IDs, and path.Match treats it as an ordinary character.
+const slashStandIn = "\x00"
+
+// globMatch reports whether name matches pattern, using the syntax of
+// path.Match except that * and ? also match /. Environment IDs such as
+// feature/login often contain slashes, and release* must still protect
+// release/1.2.
+func globMatch(pattern, name string) (bool, error) {
+	return path.Match(strings.ReplaceAll(pattern, "/", slashStandIn), strings.ReplaceAll(name, "/", slashStandIn))
+}
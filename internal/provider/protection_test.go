@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+func TestEnvironmentProtectionMatch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		id       string
+		want     string
+	}{
+		{patterns: []string{"main"}, id: "main", want: "main"},
+		{patterns: []string{"main"}, id: "maintenance", want: ""},
+		{patterns: []string{"release*"}, id: "release/1.2", want: "release*"},
+		{patterns: []string{"release/*"}, id: "release/1.2/hotfix", want: "release/*"},
+		{patterns: []string{"*"}, id: "feature/login", want: "*"},
+		{patterns: []string{"feature?login"}, id: "feature/login", want: "feature?login"},
+		{patterns: []string{"release-[0-9]*"}, id: "release-2/rc", want: "release-[0-9]*"},
+		{patterns: []string{"release-[0-9]*"}, id: "release-next", want: ""},
+		{patterns: []string{"staging", "feature/*"}, id: "feature/login", want: "feature/*"},
+		{patterns: []string{"feature/*"}, id: "features", want: ""},
+	}
+
+	for _, tt := range tests {
+		protection := environmentProtection{patterns: tt.patterns}
+		got, ok := protection.match(&platformsh.Environment{ID: tt.id})
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("match(%q) with patterns %q = %q, %t; want %q", tt.id, tt.patterns, got, ok, tt.want)
+		}
+	}
+}
+
+func TestEnvironmentProtectionCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		protection environmentProtection
+		env        platformsh.Environment
+		allowed    bool
+	}{
+		{
+			name:    "development environment",
+			env:     platformsh.Environment{ID: "feature/login", Type: "development"},
+			allowed: true,
+		},
+		{
+			name:    "production environment",
+			env:     platformsh.Environment{ID: "main", Type: environmentTypeProduction},
+			allowed: false,
+		},
+		{
+			name:       "production environment with allow_production_destroy",
+			protection: environmentProtection{allowProductionDestroy: true},
+			env:        platformsh.Environment{ID: "main", Type: environmentTypeProduction},
+			allowed:    true,
+		},
+		{
+			name:       "allow_production_destroy still honours patterns",
+			protection: environmentProtection{patterns: []string{"main"}, allowProductionDestroy: true},
+			env:        platformsh.Environment{ID: "main", Type: environmentTypeProduction},
+			allowed:    false,
+		},
+		{
+			name:       "protected by a pattern across a slash",
+			protection: environmentProtection{patterns: []string{"release*"}},
+			env:        platformsh.Environment{ID: "release/1.2", Type: "staging"},
+			allowed:    false,
+		},
+		{
+			name:       "protected by name",
+			protection: environmentProtection{patterns: []string{"legacy"}},
+			env:        platformsh.Environment{ID: "old-id", Name: "legacy", Type: "development"},
+			allowed:    false,
+		},
+		{
+			name:       "unknown patterns protect everything",
+			protection: environmentProtection{patternsUnknown: true, allowProductionDestroy: true},
+			env:        platformsh.Environment{ID: "feature/login", Type: "development"},
+			allowed:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			if got := tt.protection.check(&diags, "delete", &tt.env); got != tt.allowed {
+				t.Fatalf("check() = %t, want %t", got, tt.allowed)
+			}
+			if diags.HasError() == tt.allowed {
+				t.Fatalf("check() returned %t with diagnostics %v", tt.allowed, diags)
+			}
+		})
+	}
+}
+
+func TestNewEnvironmentProtection(t *testing.T) {
+	ctx := context.Background()
+
+	var diags diag.Diagnostics
+	patterns := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("release*"), types.StringValue("[unclosed")})
+	newEnvironmentProtection(ctx, &diags, patterns, types.BoolNull())
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("got %d errors for one invalid pattern: %v", diags.ErrorsCount(), diags)
+	}
+
+	diags = nil
+	protection := newEnvironmentProtection(ctx, &diags, types.ListUnknown(types.StringType), types.BoolValue(true))
+	if diags.HasError() || !protection.patternsUnknown || !protection.allowProductionDestroy {
+		t.Fatalf("unexpected protection for unknown patterns: %+v, %v", protection, diags)
+	}
+}
@@ -2,30 +2,75 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
 )
 
 // Ensure provider implementation satisfies the provider.Provider interface.
 var _ provider.Provider = &platformshProvider{}
 
-// New creates a new platformsh provider.
-func New() provider.Provider {
-	return &platformshProvider{}
+// New returns a constructor for the platformsh provider, reporting the given
+// version to Terraform and to the Platform.sh API.
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &platformshProvider{version: version}
+	}
 }
 
 // platformshProvider defines the provider implementation.
 type platformshProvider struct {
-	client *platformsh.Client
+	// version is the provider build version, or "dev" for local builds.
+	version string
+	client  *platformsh.Client
+}
+
+// platformshProviderModel describes the provider data model.
+type platformshProviderModel struct {
+	APIToken       types.String `tfsdk:"api_token"`
+	AccessToken    types.String `tfsdk:"access_token"`
+	ClientID       types.String `tfsdk:"client_id"`
+	ClientSecret   types.String `tfsdk:"client_secret"`
+	CLISessionFile types.String `tfsdk:"cli_session_file"`
+	UseCLISession  types.Bool   `tfsdk:"use_cli_session"`
+
+	ProjectID      types.String `tfsdk:"project_id"`
+	OrganizationID types.String `tfsdk:"organization_id"`
+
+	ProtectedEnvironments  types.List `tfsdk:"protected_environments"`
+	AllowProductionDestroy types.Bool `tfsdk:"allow_production_destroy"`
+
+	APIURL  types.String `tfsdk:"api_url"`
+	AuthURL types.String `tfsdk:"auth_url"`
+
+	HTTPSProxy         types.String `tfsdk:"https_proxy"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	CACertPEM          types.String `tfsdk:"ca_cert_pem"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	RequestTimeout     types.Int64  `tfsdk:"request_timeout"`
+
+	RequestsPerSecond     types.Float64 `tfsdk:"requests_per_second"`
+	MaxConcurrentRequests types.Int64   `tfsdk:"max_concurrent_requests"`
+
+	MaxRetries   types.Int64 `tfsdk:"max_retries"`
+	RetryWaitMin types.Int64 `tfsdk:"retry_wait_min"`
+	RetryWaitMax types.Int64 `tfsdk:"retry_wait_max"`
 }
 
 // Metadata returns the provider type name.
 func (p *platformshProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "platformsh"
+	resp.Version = p.version
 }
 
 // Schema defines the provider-level schema for configuration data.
@@ -33,9 +78,97 @@ func (p *platformshProvider) Schema(ctx context.Context, req provider.SchemaRequ
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Platform.sh provider",
 		Attributes: map[string]schema.Attribute{
+			"project_id": schema.StringAttribute{
+				MarkdownDescription: "Default project for resources and data sources that do not set their own `project_id`.",
+				Optional:            true,
+			},
+			"organization_id": schema.StringAttribute{
+				MarkdownDescription: "Default organization for data sources that do not set their own `organization_id`.",
+				Optional:            true,
+			},
+			"protected_environments": schema.ListAttribute{
+				MarkdownDescription: "Names or glob patterns, such as `release-*`, of environments that must never be deleted or replaced. Plans that would do so fail. `*` matches any characters, including the `/` of IDs such as `release/1.2`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"allow_production_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Allow plans that delete or replace environments of type `production`. Defaults to `false`.",
+				Optional:            true,
+			},
 			"api_token": schema.StringAttribute{
-				MarkdownDescription: "API token for Platform.sh",
-				Required:            true,
+				MarkdownDescription: "API token for Platform.sh. May also be set with the `PLATFORMSH_CLI_TOKEN` or `UPSUN_CLI_TOKEN` environment variable.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"access_token": schema.StringAttribute{
+				MarkdownDescription: "Pre-issued OAuth2 access token, used as is instead of exchanging a credential. May also be set with the `PLATFORMSH_ACCESS_TOKEN` environment variable.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"client_id": schema.StringAttribute{
+				MarkdownDescription: "ID of a custom OAuth2 client to authenticate with through the client credentials grant. May also be set with the `PLATFORMSH_CLIENT_ID` environment variable.",
+				Optional:            true,
+			},
+			"client_secret": schema.StringAttribute{
+				MarkdownDescription: "Secret of the OAuth2 client set in `client_id`. May also be set with the `PLATFORMSH_CLIENT_SECRET` environment variable.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"cli_session_file": schema.StringAttribute{
+				MarkdownDescription: "Session file of a logged-in Platform.sh or Upsun CLI to take the access token from. May also be set with the `PLATFORMSH_CLI_SESSION_FILE` environment variable. Defaults to the CLIs' own session locations.",
+				Optional:            true,
+			},
+			"use_cli_session": schema.BoolAttribute{
+				MarkdownDescription: "Fall back to the session of a logged-in Platform.sh or Upsun CLI when no other credential is configured. Defaults to `true`.",
+				Optional:            true,
+			},
+			"api_url": schema.StringAttribute{
+				MarkdownDescription: "Base URL of the Platform.sh API. May also be set with the `PLATFORMSH_API_URL` environment variable. Defaults to `" + platformsh.DefaultAPIURL + "`.",
+				Optional:            true,
+			},
+			"auth_url": schema.StringAttribute{
+				MarkdownDescription: "Base URL of the Platform.sh OAuth2 server. May also be set with the `PLATFORMSH_AUTH_URL` environment variable. Defaults to `" + platformsh.DefaultAuthURL + "`.",
+				Optional:            true,
+			},
+			"https_proxy": schema.StringAttribute{
+				MarkdownDescription: "URL of the proxy to send API and authentication requests through. When not set, the `HTTPS_PROXY` and `NO_PROXY` environment variables apply.",
+				Optional:            true,
+			},
+			"ca_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM file with extra CA certificates to trust, e.g. for a TLS-intercepting proxy. May also be set with the `PLATFORMSH_CA_CERT_FILE` environment variable.",
+				Optional:            true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM-encoded extra CA certificates to trust, as an alternative to `ca_cert_file`.",
+				Optional:            true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Skip TLS certificate verification. Only use this with a local stand-in of the API. Defaults to `false`.",
+				Optional:            true,
+			},
+			"request_timeout": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Timeout in seconds for a single HTTP request, not counting retries. Defaults to `%d`.", int64(platformsh.DefaultRequestTimeout/time.Second)),
+				Optional:            true,
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: fmt.Sprintf("Sustained rate of requests sent to Platform.sh, shared by all resources and data sources using this provider. Defaults to `%d`.", platformsh.DefaultRequestsPerSecond),
+				Optional:            true,
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of requests in flight at once, shared by all resources and data sources using this provider. Defaults to `%d`.", platformsh.DefaultMaxConcurrentRequests),
+				Optional:            true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of times a request is retried after a rate limit, server error or network failure. Requests that create or change objects are only retried when the API did not process them. Set to `0` to disable retries. Defaults to `%d`.", platformsh.DefaultMaxRetries),
+				Optional:            true,
+			},
+			"retry_wait_min": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Minimum time in seconds to wait before retrying a request. Defaults to `%d`.", int64(platformsh.DefaultRetryWaitMin/time.Second)),
+				Optional:            true,
+			},
+			"retry_wait_max": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum time in seconds to wait before retrying a request, including waits requested by the API through `Retry-After`. Defaults to `%d`.", int64(platformsh.DefaultRetryWaitMax/time.Second)),
+				Optional:            true,
 			},
 		},
 	}
@@ -43,9 +176,7 @@ func (p *platformshProvider) Schema(ctx context.Context, req provider.SchemaRequ
 
 // Configure prepares the provider with the given configuration.
 func (p *platformshProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
-	var config struct {
-		APIToken string `tfsdk:"api_token"`
-	}
+	var config platformshProviderModel
 
 	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
@@ -53,18 +184,109 @@ func (p *platformshProvider) Configure(ctx context.Context, req provider.Configu
 		return
 	}
 
-	client, err := platformsh.NewClient(config.APIToken)
+	// Credentials are only exchanged for an access token by the first API
+	// request, so plans and validations that touch no Platform.sh objects work
+	// offline. Missing credentials are likewise reported by the resource or
+	// data source that needs them.
+	var credentials platformsh.Credentials
+	if config.APIURL.IsUnknown() || config.AuthURL.IsUnknown() {
+		// Falling back to the default endpoints would send the credentials
+		// meant for another API to Platform.sh.
+		tflog.Debug(ctx, "Platform.sh endpoints are not known yet")
+		credentials = &platformsh.MissingCredentials{
+			Reason: "api_url or auth_url depends on values that are not known until apply",
+		}
+	} else if config.credentialsUnknown() {
+		tflog.Debug(ctx, "Platform.sh credentials are not known yet")
+		credentials = &platformsh.MissingCredentials{
+			Reason: "the provider credentials depend on values that are not known until apply",
+		}
+	} else if resolved, origin, err := config.resolveCredentials(); err != nil {
+		tflog.Debug(ctx, "No Platform.sh credentials configured")
+		credentials = &platformsh.MissingCredentials{
+			Reason: "set api_token (or the PLATFORMSH_CLI_TOKEN or UPSUN_CLI_TOKEN environment variable), " +
+				"access_token, or client_id and client_secret in the provider configuration, or log in with the Platform.sh CLI",
+		}
+	} else {
+		tflog.Debug(ctx, "Using Platform.sh credentials", map[string]interface{}{
+			"source": resolved.Source(),
+			"origin": origin,
+		})
+		credentials = resolved
+	}
+
+	clientConfig := platformsh.Config{
+		Credentials:  credentials,
+		UserAgent:    p.userAgent(req.TerraformVersion),
+		APIURL:       stringValueOrEnv(config.APIURL, "PLATFORMSH_API_URL"),
+		AuthURL:      stringValueOrEnv(config.AuthURL, "PLATFORMSH_AUTH_URL"),
+		ProxyURL:     config.HTTPSProxy.ValueString(),
+		CACertFile:   stringValueOrEnv(config.CACertFile, "PLATFORMSH_CA_CERT_FILE"),
+		CACertPEM:    config.CACertPEM.ValueString(),
+		MaxRetries:   platformsh.DefaultMaxRetries,
+		RetryWaitMin: platformsh.DefaultRetryWaitMin,
+		RetryWaitMax: platformsh.DefaultRetryWaitMax,
+	}
+
+	if isSet(config.InsecureSkipVerify) {
+		clientConfig.InsecureSkipVerify = config.InsecureSkipVerify.ValueBool()
+	}
+	if isSet(config.RequestTimeout) {
+		if config.RequestTimeout.ValueInt64() <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("request_timeout"), "Invalid Request Timeout", "request_timeout must be positive.")
+		}
+		clientConfig.RequestTimeout = time.Duration(config.RequestTimeout.ValueInt64()) * time.Second
+	}
+	if isSet(config.RequestsPerSecond) {
+		if config.RequestsPerSecond.ValueFloat64() <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("requests_per_second"), "Invalid Rate Limit", "requests_per_second must be positive.")
+		}
+		clientConfig.RequestsPerSecond = config.RequestsPerSecond.ValueFloat64()
+	}
+	if isSet(config.MaxConcurrentRequests) {
+		if config.MaxConcurrentRequests.ValueInt64() <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_requests"), "Invalid Rate Limit", "max_concurrent_requests must be positive.")
+		}
+		clientConfig.MaxConcurrentRequests = int(config.MaxConcurrentRequests.ValueInt64())
+	}
+	if isSet(config.MaxRetries) {
+		if config.MaxRetries.ValueInt64() < 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Invalid Retry Configuration", "max_retries must not be negative.")
+		}
+		clientConfig.MaxRetries = int(config.MaxRetries.ValueInt64())
+	}
+	if isSet(config.RetryWaitMin) {
+		clientConfig.RetryWaitMin = time.Duration(config.RetryWaitMin.ValueInt64()) * time.Second
+	}
+	if isSet(config.RetryWaitMax) {
+		clientConfig.RetryWaitMax = time.Duration(config.RetryWaitMax.ValueInt64()) * time.Second
+	}
+	if clientConfig.RetryWaitMin <= 0 || clientConfig.RetryWaitMax < clientConfig.RetryWaitMin {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_wait_min"), "Invalid Retry Configuration", "retry_wait_min must be positive and not greater than retry_wait_max.")
+	}
+	protection := newEnvironmentProtection(ctx, &resp.Diagnostics, config.ProtectedEnvironments, config.AllowProductionDestroy)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client, err := platformsh.NewClientFromConfig(ctx, clientConfig)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to create Platform.sh client",
-			err.Error(),
+			fmt.Sprintf("%s\n\nProvider version: %s", err, p.version),
 		)
 		return
 	}
 
 	p.client = client
-	resp.DataSourceData = client
-	resp.ResourceData = client
+	data := &providerData{
+		client:         client,
+		projectID:      config.ProjectID,
+		organizationID: config.OrganizationID,
+		protection:     protection,
+	}
+	resp.DataSourceData = data
+	resp.ResourceData = data
 }
 
 // Resources returns the resource implementations supported by this provider.
@@ -81,3 +303,66 @@ func (p *platformshProvider) DataSources(ctx context.Context) []func() datasourc
 		NewEnvironmentDataSource,
 	}
 }
+
+// userAgent identifies the provider build and the Terraform version driving
+// it to Platform.sh.
+func (p *platformshProvider) userAgent(terraformVersion string) string {
+	userAgent := "terraform-provider-platformsh/" + p.version
+	if terraformVersion != "" {
+		userAgent += " terraform/" + terraformVersion
+	}
+	return userAgent
+}
+
+// stringValueOrEnv returns the configured value, or the value of the first
+// named environment variable that is set when the attribute is not.
+func stringValueOrEnv(value types.String, envVars ...string) string {
+	if isSet(value) {
+		return value.ValueString()
+	}
+	return lookupEnv(envVars...)
+}
+
+// lookupEnv returns the value of the first named environment variable that
+// is set.
+func lookupEnv(envVars ...string) string {
+	for _, envVar := range envVars {
+		if v := os.Getenv(envVar); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// stringPointer returns a pointer to the value if it is set, and nil
+// otherwise.
+func stringPointer(value types.String) *string {
+	if !isSet(value) {
+		return nil
+	}
+	v := value.ValueString()
+	return &v
+}
+
+// boolPointer returns a pointer to the value if it is set, and nil
+// otherwise.
+func boolPointer(value types.Bool) *bool {
+	if !isSet(value) {
+		return nil
+	}
+	v := value.ValueBool()
+	return &v
+}
+
+// boolValueOr returns the value if it is set, and fallback otherwise.
+func boolValueOr(value types.Bool, fallback bool) bool {
+	if isSet(value) {
+		return value.ValueBool()
+	}
+	return fallback
+}
+
+// isSet reports whether a configuration value is present and known.
+func isSet(value attr.Value) bool {
+	return !value.IsNull() && !value.IsUnknown()
+}
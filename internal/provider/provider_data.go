@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+// providerData is what Configure hands to resources and data sources.
+type providerData struct {
+	client platformsh.API
+
+	// projectID and organizationID are the provider-level defaults. They
+	// are null when unset and unknown while they depend on other resources.
+	projectID      types.String
+	organizationID types.String
+
+	protection environmentProtection
+}
+
+// configureProviderData extracts the provider data passed to a resource or
+// data source Configure method. It returns nil, without a diagnostic, when
+// the provider has not been configured yet.
+func configureProviderData(diags *diag.Diagnostics, data any, kind string) *providerData {
+	if data == nil {
+		return nil
+	}
+
+	pd, ok := data.(*providerData)
+	if !ok {
+		diags.AddError(
+			fmt.Sprintf("Unexpected %s Configure Type", kind),
+			fmt.Sprintf("Expected *providerData, got: %T. Please report this issue to the provider developers.", data),
+		)
+		return nil
+	}
+
+	return pd
+}
+
+// withDefault returns value, or fallback when value is not configured.
+func withDefault(value, fallback types.String) types.String {
+	if value.IsNull() {
+		return fallback
+	}
+	return value
+}
+
+// addMissingProjectError explains that no project was set for the
+// attribute at p, neither there nor on the provider.
+func addMissingProjectError(diags *diag.Diagnostics, p path.Path) {
+	diags.AddAttributeError(
+		p,
+		"Missing Project ID",
+		"No project is set. Set project_id here, or set a default project_id in the platformsh provider configuration.",
+	)
+}
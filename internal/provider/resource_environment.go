@@ -3,10 +3,20 @@ package provider
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
 )
 
@@ -14,6 +24,8 @@ import (
 var _ resource.Resource = &EnvironmentResource{}
 var _ resource.ResourceWithConfigure = &EnvironmentResource{}
 var _ resource.ResourceWithImportState = &EnvironmentResource{}
+var _ resource.ResourceWithModifyPlan = &EnvironmentResource{}
+var _ resource.ResourceWithValidateConfig = &EnvironmentResource{}
 
 func NewEnvironmentResource() resource.Resource {
 	return &EnvironmentResource{}
@@ -21,7 +33,10 @@ func NewEnvironmentResource() resource.Resource {
 
 // EnvironmentResource defines the resource implementation.
 type EnvironmentResource struct {
-	client *platformsh.Client
+	client platformsh.API
+	// defaultProjectID is the provider-level project_id.
+	defaultProjectID types.String
+	protection       environmentProtection
 }
 
 // EnvironmentResourceModel describes the resource data model.
@@ -30,14 +45,24 @@ type EnvironmentResourceModel struct {
 	ProjectID      types.String `tfsdk:"project_id"`
 	Name           types.String `tfsdk:"name"`
 	Title          types.String `tfsdk:"title"`
+	Parent         types.String `tfsdk:"parent"`
+	CloneParent    types.Bool   `tfsdk:"clone_parent"`
 	Type           types.String `tfsdk:"type"`
 	Status         types.String `tfsdk:"status"`
+	DesiredStatus  types.String `tfsdk:"desired_status"`
 	DefaultDomain  types.String `tfsdk:"default_domain"`
 	EnableSMTP     types.Bool   `tfsdk:"enable_smtp"`
 	RestrictRobots types.Bool   `tfsdk:"restrict_robots"`
 	CreatedAt      types.String `tfsdk:"created_at"`
+	UpdatedAt      types.String `tfsdk:"updated_at"`
+
+	KeepInactiveOnDestroy types.Bool `tfsdk:"keep_inactive_on_destroy"`
 }
 
+// defaultParentEnvironment is branched from when neither the configuration
+// nor the project names a parent.
+const defaultParentEnvironment = "main"
+
 func (r *EnvironmentResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_environment"
 }
@@ -48,67 +73,217 @@ func (r *EnvironmentResource) Schema(ctx context.Context, req resource.SchemaReq
 			"id": schema.StringAttribute{
 				Description: "ID of the environment",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"project_id": schema.StringAttribute{
-				Description: "ID of the project",
-				Required:    true,
+				Description: "ID of the project. Defaults to the provider project_id. Changing it replaces the environment",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"name": schema.StringAttribute{
-				Description: "Name of the environment",
+				Description: "Name of the environment. Changing it replaces the environment",
 				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"title": schema.StringAttribute{
-				Description: "Title of the environment",
+				Description: "Title of the environment. Defaults to its name",
 				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"parent": schema.StringAttribute{
+				Description: "ID of the environment to branch from. Defaults to the default branch of the project",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"clone_parent": schema.BoolAttribute{
+				Description: "Copy the data of the parent into the new environment when it is created. Defaults to true. Changing it later has no effect",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
 			},
 			"type": schema.StringAttribute{
-				Description: "Type of the environment",
+				Description: "Type of the environment: development or staging. Defaults to development",
+				Optional:    true,
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
+			// status is left unknown whenever an update is planned: it changes
+			// with desired_status, and with activities such as deployments.
 			"status": schema.StringAttribute{
 				Description: "Status of the environment",
 				Computed:    true,
 			},
+			"desired_status": schema.StringAttribute{
+				Description: "Status to keep the environment in: active, inactive or paused. The environment is activated, deactivated, paused or resumed to match. Left alone when unset",
+				Optional:    true,
+			},
 			"default_domain": schema.StringAttribute{
 				Description: "Default domain of the environment",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"enable_smtp": schema.BoolAttribute{
-				Description: "Enable SMTP for the environment",
+				Description: "Enable SMTP for the environment. When unset, the Platform.sh default is kept: enabled on production environments only",
 				Optional:    true,
 				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"restrict_robots": schema.BoolAttribute{
-				Description: "Restrict robots for the environment",
+				Description: "Restrict robots for the environment. When unset, the Platform.sh default is kept: robots are kept off environments other than production",
 				Optional:    true,
 				Computed:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"created_at": schema.StringAttribute{
 				Description: "Creation time of the environment",
 				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"updated_at": schema.StringAttribute{
+				Description: "Time of the last change to the environment",
+				Computed:    true,
+			},
+			"keep_inactive_on_destroy": schema.BoolAttribute{
+				Description: "On destroy, only deactivate the environment and keep its git branch. Defaults to false",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
 			},
 		},
 	}
 }
 
 func (r *EnvironmentResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	if req.ProviderData == nil {
+	data := configureProviderData(&resp.Diagnostics, req.ProviderData, "Resource")
+	if data == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*platformsh.Client)
-	if !ok {
-		resp.Diagnostics.AddError(
-			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *platformsh.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
-		)
+	r.client = data.client
+	r.defaultProjectID = data.projectID
+	r.protection = data.protection
+}
+
+func (r *EnvironmentResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var desiredStatus types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("desired_status"), &desiredStatus)...)
+	if !isSet(desiredStatus) || slices.Contains(desiredStatuses, desiredStatus.ValueString()) {
 		return
 	}
 
-	r.client = client
+	resp.Diagnostics.AddAttributeError(
+		path.Root("desired_status"),
+		"Invalid Desired Status",
+		fmt.Sprintf("desired_status must be one of %s, got %q.", strings.Join(desiredStatuses, ", "), desiredStatus.ValueString()),
+	)
+}
+
+// ModifyPlan fills in the provider-level project_id when the resource does
+// not set its own, so that the plan shows the project that will be used, and
+// fails plans that would destroy a protected environment.
+func (r *EnvironmentResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		if !req.State.Raw.IsNull() {
+			r.checkDestroy(ctx, &resp.Diagnostics, req.State, "delete")
+		}
+		return
+	}
+
+	var configProjectID types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("project_id"), &configProjectID)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	projectID := withDefault(configProjectID, r.defaultProjectID)
+	if projectID.IsNull() {
+		addMissingProjectError(&resp.Diagnostics, path.Root("project_id"))
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var state EnvironmentResourceModel
+	var name, desiredStatus types.String
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("name"), &name)...)
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("desired_status"), &desiredStatus)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// An environment cannot move between projects. A configured project_id
+	// is handled by its RequiresReplace plan modifier, but an inherited one
+	// is only resolved here.
+	if configProjectID.IsNull() && !projectID.Equal(state.ProjectID) {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("project_id"))
+	}
+
+	// Replacing destroys the environment just like deleting it does. Plan
+	// modifiers do not report their replacements here, so compare the
+	// identity attributes directly.
+	if len(resp.RequiresReplace) > 0 || !projectID.Equal(state.ProjectID) || !name.Equal(state.Name) {
+		r.checkDestroy(ctx, &resp.Diagnostics, req.State, "replace")
+	}
+
+	// Plan an update when the environment drifted from desired_status, for
+	// instance because someone paused it by hand. A dirty environment is
+	// only busy with an activity, such as a deployment, and not drifted.
+	if isSet(desiredStatus) && isSet(state.Status) && !desiredStatus.Equal(state.Status) &&
+		state.Status.ValueString() != platformsh.EnvironmentStatusDirty {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("status"), types.StringUnknown())...)
+	}
+}
+
+// checkDestroy applies the provider protection settings to the environment
+// recorded in state.
+func (r *EnvironmentResource) checkDestroy(ctx context.Context, diags *diag.Diagnostics, state tfsdk.State, action string) {
+	var data EnvironmentResourceModel
+	diags.Append(state.Get(ctx, &data)...)
+	if diags.HasError() {
+		return
+	}
+
+	r.protection.check(diags, action, &platformsh.Environment{
+		ID:   data.ID.ValueString(),
+		Name: data.Name.ValueString(),
+		Type: data.Type.ValueString(),
+	})
 }
 
 func (r *EnvironmentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		addUnconfiguredError(&resp.Diagnostics)
+		return
+	}
+
 	var data EnvironmentResourceModel
 
 	// Read Terraform plan data into the model
@@ -117,37 +292,109 @@ func (r *EnvironmentResource) Create(ctx context.Context, req resource.CreateReq
 		return
 	}
 
-	// Prepare environment data
-	environment := &platformsh.Environment{
-		Name:           data.Name.ValueString(),
-		Title:          data.Title.ValueString(),
-		EnableSMTP:     data.EnableSMTP.ValueBool(),
-		RestrictRobots: data.RestrictRobots.ValueBool(),
+	projectID := data.ProjectID.ValueString()
+
+	// Branch from the project's default branch unless told otherwise
+	parentID := data.Parent.ValueString()
+	if !isSet(data.Parent) {
+		project, err := r.client.GetProject(ctx, projectID)
+		if err != nil {
+			addClientError(&resp.Diagnostics, "read project", err)
+			return
+		}
+		parentID = project.DefaultBranch
+		if parentID == "" {
+			parentID = defaultParentEnvironment
+		}
+	}
+
+	// Make sure the parent can be branched before trying
+	parent, err := r.client.GetEnvironment(ctx, projectID, parentID)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "read parent environment", err)
+		return
+	}
+	if !checkEnvironmentAllows(&resp.Diagnostics, parent, platformsh.LinkBranch, "branch from") {
+		return
 	}
 
 	// Call Platform.sh API to create the environment
-	createdEnvironment, err := r.client.CreateEnvironment(data.ProjectID.ValueString(), "default", environment)
+	operation, err := r.client.CreateEnvironment(ctx, projectID, parentID, &platformsh.BranchRequest{
+		Name:        data.Name.ValueString(),
+		Title:       data.Title.ValueString(),
+		Type:        data.Type.ValueString(),
+		CloneParent: data.CloneParent.ValueBool(),
+	})
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Client Error",
-			"Unable to create environment, got error: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, "create environment", err)
+		return
+	}
+
+	// The environment exists from here on. Record it straight away, so that
+	// if a later step fails Terraform taints it instead of losing track of it.
+	data.ID = data.Name
+	data.nullUnknown()
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+
+	// Wait for the branch activity before reading the new environment back
+	if _, err := r.client.WaitForOperation(ctx, projectID, operation); err != nil {
+		addClientError(&resp.Diagnostics, "create environment", err)
 		return
 	}
 
-	// Save relevant data into Terraform state
-	data.ID = types.StringValue("newly_generated_id") // Replace with actual ID if available
-	data.Status = types.StringValue(createdEnvironment.Status)
-	data.CreatedAt = types.StringValue("")      // Set to actual value if available
-	data.DefaultDomain = types.StringValue("")  // Set to actual value if available
-	data.EnableSMTP = types.BoolValue(true)     // Set to actual value if available
-	data.RestrictRobots = types.BoolValue(true) // Set to actual value if available
-	data.Type = types.StringValue("")           // Set to actual value if available
+	environment, err := r.client.GetEnvironment(ctx, projectID, data.Name.ValueString())
+	if err != nil {
+		addClientError(&resp.Diagnostics, "read environment after create", err)
+		return
+	}
+	data.setEnvironment(environment)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+
+	// Branching does not take these settings, so apply them afterwards
+	enableSMTP := boolValueOr(data.EnableSMTP, environment.EnableSMTP)
+	restrictRobots := boolValueOr(data.RestrictRobots, environment.RestrictRobots)
+	if enableSMTP != environment.EnableSMTP || restrictRobots != environment.RestrictRobots {
+		operation, err := r.client.UpdateEnvironment(ctx, projectID, environment.ID, &platformsh.EnvironmentPatch{
+			EnableSMTP:     &enableSMTP,
+			RestrictRobots: &restrictRobots,
+		})
+		if err != nil {
+			addClientError(&resp.Diagnostics, "update environment after create", err)
+			return
+		}
+		if _, err := r.client.WaitForOperation(ctx, projectID, operation); err != nil {
+			addClientError(&resp.Diagnostics, "update environment after create", err)
+			return
+		}
+
+		environment, err = r.client.GetEnvironment(ctx, projectID, environment.ID)
+		if err != nil {
+			addClientError(&resp.Diagnostics, "read environment after create", err)
+			return
+		}
+		data.setEnvironment(environment)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	}
+
+	if isSet(data.DesiredStatus) {
+		environment = r.setEnvironmentStatus(ctx, &resp.Diagnostics, projectID, environment, data.DesiredStatus.ValueString())
+		if environment == nil {
+			return
+		}
+	}
+
+	// Save the environment as Platform.sh reports it into Terraform state
+	data.setEnvironment(environment)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *EnvironmentResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		addUnconfiguredError(&resp.Diagnostics)
+		return
+	}
+
 	var data EnvironmentResourceModel
 
 	// Read Terraform prior state data into the model
@@ -157,66 +404,106 @@ func (r *EnvironmentResource) Read(ctx context.Context, req resource.ReadRequest
 	}
 
 	// Call Platform.sh API to read the environment
-	environment, err := r.client.GetEnvironment(data.ProjectID.ValueString(), data.ID.ValueString())
+	environment, err := r.client.GetEnvironment(ctx, data.ProjectID.ValueString(), data.ID.ValueString())
+	if platformsh.IsNotFound(err) {
+		// The environment was deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Client Error",
-			"Unable to read environment, got error: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, "read environment", err)
 		return
 	}
 
 	// Save updated data into Terraform state
-	data.Name = types.StringValue(environment.Name)
-	data.Title = types.StringValue(environment.Title)
-	data.Type = types.StringValue(environment.Type)
-	data.Status = types.StringValue(environment.Status)
-	data.DefaultDomain = types.StringValue(environment.DefaultDomain)
-	data.EnableSMTP = types.BoolValue(environment.EnableSMTP)
-	data.RestrictRobots = types.BoolValue(environment.RestrictRobots)
-	data.CreatedAt = types.StringValue(environment.CreatedAt)
+	data.setEnvironment(environment)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *EnvironmentResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data EnvironmentResourceModel
+	if r.client == nil {
+		addUnconfiguredError(&resp.Diagnostics)
+		return
+	}
 
-	// Read Terraform plan data into the model
+	var data, state EnvironmentResourceModel
+
+	// Read Terraform plan data and prior state into the models
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Call Platform.sh API to update the environment
-	environment := &platformsh.Environment{
-		Name:           data.Name.ValueString(),
-		Title:          data.Title.ValueString(),
-		EnableSMTP:     data.EnableSMTP.ValueBool(),
-		RestrictRobots: data.RestrictRobots.ValueBool(),
-	}
-
-	updatedEnvironment, err := r.client.UpdateEnvironment(data.ProjectID.ValueString(), data.ID.ValueString(), environment)
+	updatedEnvironment, err := r.client.GetEnvironment(ctx, data.ProjectID.ValueString(), data.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Client Error",
-			"Unable to update environment, got error: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, "read environment", err)
 		return
 	}
 
+	// Send only the settings the plan changes, leaving alone anything the
+	// configuration leaves to Platform.sh. clone_parent only matters when
+	// branching and is never sent.
+	patch := platformsh.EnvironmentPatch{
+		Title:          changedString(data.Title, state.Title),
+		Type:           changedString(data.Type, state.Type),
+		Parent:         changedString(data.Parent, state.Parent),
+		EnableSMTP:     changedBool(data.EnableSMTP, state.EnableSMTP),
+		RestrictRobots: changedBool(data.RestrictRobots, state.RestrictRobots),
+	}
+	if patch != (platformsh.EnvironmentPatch{}) {
+		// Let a running activity, such as a deployment, finish first
+		if updatedEnvironment.Status == platformsh.EnvironmentStatusDirty {
+			updatedEnvironment, err = r.client.WaitForEnvironment(ctx, data.ProjectID.ValueString(), data.ID.ValueString())
+			if err != nil {
+				addClientError(&resp.Diagnostics, "wait for environment", err)
+				return
+			}
+		}
+		if !checkEnvironmentAllows(&resp.Diagnostics, updatedEnvironment, platformsh.LinkEdit, "edit") {
+			return
+		}
+
+		// Call Platform.sh API to update the environment
+		operation, err := r.client.UpdateEnvironment(ctx, data.ProjectID.ValueString(), data.ID.ValueString(), &patch)
+		if err != nil {
+			addClientError(&resp.Diagnostics, "update environment", err)
+			return
+		}
+
+		// Some changes redeploy the environment; wait for that to finish
+		if _, err := r.client.WaitForOperation(ctx, data.ProjectID.ValueString(), operation); err != nil {
+			addClientError(&resp.Diagnostics, "update environment", err)
+			return
+		}
+
+		updatedEnvironment, err = r.client.GetEnvironment(ctx, data.ProjectID.ValueString(), data.ID.ValueString())
+		if err != nil {
+			addClientError(&resp.Diagnostics, "read environment after update", err)
+			return
+		}
+	}
+
+	if isSet(data.DesiredStatus) {
+		updatedEnvironment = r.setEnvironmentStatus(ctx, &resp.Diagnostics, data.ProjectID.ValueString(), updatedEnvironment, data.DesiredStatus.ValueString())
+		if updatedEnvironment == nil {
+			return
+		}
+	}
+
 	// Save updated data into Terraform state
-	data.Type = types.StringValue(updatedEnvironment.Type)
-	data.Status = types.StringValue(updatedEnvironment.Status)
-	data.DefaultDomain = types.StringValue(updatedEnvironment.DefaultDomain)
-	data.EnableSMTP = types.BoolValue(updatedEnvironment.EnableSMTP)
-	data.RestrictRobots = types.BoolValue(updatedEnvironment.RestrictRobots)
-	data.CreatedAt = types.StringValue(updatedEnvironment.CreatedAt)
+	data.setEnvironment(updatedEnvironment)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *EnvironmentResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		addUnconfiguredError(&resp.Diagnostics)
+		return
+	}
+
 	var data EnvironmentResourceModel
 
 	// Read Terraform prior state data into the model
@@ -225,13 +512,74 @@ func (r *EnvironmentResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	projectID := data.ProjectID.ValueString()
+
+	current, err := r.client.GetEnvironment(ctx, projectID, data.ID.ValueString())
+	if platformsh.IsNotFound(err) {
+		// Already deleted outside of Terraform.
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		addClientError(&resp.Diagnostics, "read environment", err)
+		return
+	}
+
+	// Let a running activity, such as a deployment, finish first
+	if current.Status == platformsh.EnvironmentStatusDirty {
+		current, err = r.client.WaitForEnvironment(ctx, projectID, current.ID)
+		if platformsh.IsNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		if err != nil {
+			addClientError(&resp.Diagnostics, "wait for environment", err)
+			return
+		}
+	}
+
+	// The plan was checked already, but the environment may have become
+	// production since.
+	if !r.protection.check(&resp.Diagnostics, "delete", current) {
+		return
+	}
+	if current.Status == platformsh.EnvironmentStatusDeleting {
+		tflog.Debug(ctx, "Environment is already being deleted", map[string]interface{}{"environment_id": current.ID})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Only inactive environments can be deleted
+	if current.Status != platformsh.EnvironmentStatusInactive {
+		current = r.setEnvironmentStatus(ctx, &resp.Diagnostics, projectID, current, platformsh.EnvironmentStatusInactive)
+		if current == nil {
+			return
+		}
+	}
+
+	if data.KeepInactiveOnDestroy.ValueBool() {
+		tflog.Info(ctx, "Keeping the branch of the deactivated environment", map[string]interface{}{"environment_id": current.ID})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	if !checkEnvironmentAllows(&resp.Diagnostics, current, platformsh.LinkDelete, "delete") {
+		return
+	}
+
 	// Call Platform.sh API to delete the environment
-	err := r.client.DeleteEnvironment(data.ProjectID.ValueString(), data.ID.ValueString())
+	operation, err := r.client.DeleteEnvironment(ctx, projectID, current.ID)
+	if platformsh.IsNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Client Error",
-			"Unable to delete environment, got error: "+err.Error(),
-		)
+		addClientError(&resp.Diagnostics, "delete environment", err)
+		return
+	}
+
+	if _, err := r.client.WaitForOperation(ctx, projectID, operation); err != nil {
+		addClientError(&resp.Diagnostics, "delete environment", err)
 		return
 	}
 
@@ -239,12 +587,117 @@ func (r *EnvironmentResource) Delete(ctx context.Context, req resource.DeleteReq
 	resp.State.RemoveResource(ctx)
 }
 
+// setEnvironment copies the attributes Platform.sh reports for env into the
+// model. clone_parent only matters when branching and is left alone.
+func (m *EnvironmentResourceModel) setEnvironment(env *platformsh.Environment) {
+	m.ID = types.StringValue(env.ID)
+	m.Name = types.StringValue(env.Name)
+	m.Title = types.StringValue(env.Title)
+	m.Parent = types.StringNull()
+	if env.Parent != "" {
+		m.Parent = types.StringValue(env.Parent)
+	}
+	m.Type = types.StringValue(env.Type)
+	m.Status = types.StringValue(env.Status)
+	m.DefaultDomain = types.StringValue(env.DefaultDomain)
+	m.EnableSMTP = types.BoolValue(env.EnableSMTP)
+	m.RestrictRobots = types.BoolValue(env.RestrictRobots)
+	m.CreatedAt = types.StringValue(env.CreatedAt)
+	m.UpdatedAt = types.StringValue(env.UpdatedAt)
+}
+
+// changedString returns the planned value if it is set and differs from
+// state, and nil otherwise.
+func changedString(plan, state types.String) *string {
+	if plan.Equal(state) {
+		return nil
+	}
+	return stringPointer(plan)
+}
+
+// changedBool returns the planned value if it is set and differs from state,
+// and nil otherwise.
+func changedBool(plan, state types.Bool) *bool {
+	if plan.Equal(state) {
+		return nil
+	}
+	return boolPointer(plan)
+}
+
+// nullUnknown nulls the computed attributes that are not known yet, since
+// Terraform cannot store unknown values in state.
+func (m *EnvironmentResourceModel) nullUnknown() {
+	for _, v := range []*types.String{&m.Title, &m.Parent, &m.Type, &m.Status, &m.DefaultDomain, &m.CreatedAt, &m.UpdatedAt} {
+		if v.IsUnknown() {
+			*v = types.StringNull()
+		}
+	}
+	for _, v := range []*types.Bool{&m.EnableSMTP, &m.RestrictRobots} {
+		if v.IsUnknown() {
+			*v = types.BoolNull()
+		}
+	}
+}
+
+// checkEnvironmentAllows reports whether the links of env permit the action
+// behind rel, and otherwise explains why not. Environments returned without
+// any links are not checked.
+func checkEnvironmentAllows(diags *diag.Diagnostics, env *platformsh.Environment, rel, action string) bool {
+	if len(env.Links) == 0 || env.Allows(rel) {
+		return true
+	}
+
+	switch {
+	case rel == platformsh.LinkDelete && env.Allows(platformsh.LinkDeactivate):
+		diags.AddError(
+			"Environment Is Active",
+			fmt.Sprintf("Environment %q is active and must be deactivated before it can be deleted.", env.ID),
+		)
+	case env.Allows(platformsh.LinkEdit):
+		diags.AddError(
+			"Action Not Allowed",
+			fmt.Sprintf("Unable to %s environment %q: Platform.sh does not allow this while its status is %q.", action, env.ID, env.Status),
+		)
+	default:
+		diags.AddError(
+			"Permission Denied",
+			fmt.Sprintf("You lack permission to %s environment %q.", action, env.ID),
+		)
+	}
+
+	return false
+}
+
+// ImportState accepts IDs of the form <project_id>:<environment_id>, or a
+// bare environment ID when the provider sets a default project_id. The
+// remaining attributes are filled in by the Read that follows.
 func (r *EnvironmentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Retrieve the resource ID from the import request
-	resourceID := req.ID
+	// Environment IDs may contain slashes but not colons, project IDs
+	// neither.
+	projectID, environmentID, ok := strings.Cut(req.ID, ":")
+	if !ok {
+		// An unknown default is as good as none.
+		projectID, environmentID = "", req.ID
+		if isSet(r.defaultProjectID) {
+			projectID = r.defaultProjectID.ValueString()
+		}
+	}
+	if projectID == "" || environmentID == "" {
+		detail := "The project may only be left out when the provider sets a default project_id."
+		if !ok && r.defaultProjectID.IsUnknown() {
+			detail = "The provider project_id is not known yet, so the import ID must include the project."
+		}
+		resp.Diagnostics.AddError(
+			"Invalid Import ID",
+			fmt.Sprintf("Expected an import ID of the form <project_id>:<environment_id>, got %q. %s", req.ID, detail),
+		)
+		return
+	}
 
-	// Set the resource ID in the state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &EnvironmentResourceModel{
-		ID: types.StringValue(resourceID),
-	})...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), environmentID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("project_id"), projectID)...)
+	// Platform.sh does not report these, so start from their defaults
+	// rather than planning a change right after the import.
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("clone_parent"), true)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("keep_inactive_on_destroy"), false)...)
 }
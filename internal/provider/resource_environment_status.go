@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/platformsh"
+)
+
+// maxStatusTransitions bounds the actions taken to reach a desired status;
+// the longest path, from inactive to paused, takes two.
+const maxStatusTransitions = 3
+
+// desiredStatuses are the values accepted by desired_status.
+var desiredStatuses = []string{
+	platformsh.EnvironmentStatusActive,
+	platformsh.EnvironmentStatusInactive,
+	platformsh.EnvironmentStatusPaused,
+}
+
+// statusTransition is one environment action on the way to a status.
+type statusTransition struct {
+	// rel is the link that must be present for the action.
+	rel string
+	// action describes the action in diagnostics.
+	action string
+	run    func(ctx context.Context, projectID, environmentID string) (*platformsh.OperationResponse, error)
+}
+
+// nextStatusTransition returns the action that takes an environment in
+// status from closer to target.
+func nextStatusTransition(client platformsh.API, from, target string) (statusTransition, bool) {
+	activate := statusTransition{platformsh.LinkActivate, "activate", client.ActivateEnvironment}
+	deactivate := statusTransition{platformsh.LinkDeactivate, "deactivate", client.DeactivateEnvironment}
+	pause := statusTransition{platformsh.LinkPause, "pause", client.PauseEnvironment}
+	resume := statusTransition{platformsh.LinkResume, "resume", client.ResumeEnvironment}
+
+	switch {
+	case target == platformsh.EnvironmentStatusActive && from == platformsh.EnvironmentStatusInactive:
+		return activate, true
+	case target == platformsh.EnvironmentStatusActive && from == platformsh.EnvironmentStatusPaused:
+		return resume, true
+	case target == platformsh.EnvironmentStatusPaused && from == platformsh.EnvironmentStatusActive:
+		return pause, true
+	case target == platformsh.EnvironmentStatusPaused && from == platformsh.EnvironmentStatusInactive:
+		// Only active environments can be paused.
+		return activate, true
+	case target == platformsh.EnvironmentStatusInactive && (from == platformsh.EnvironmentStatusActive || from == platformsh.EnvironmentStatusPaused):
+		return deactivate, true
+	}
+	return statusTransition{}, false
+}
+
+// setEnvironmentStatus activates, deactivates, pauses or resumes env until
+// it reaches target, waiting for each activity. An environment that is
+// dirty is waited for first. It returns the environment as it is
+// afterwards, or nil when a diagnostic was recorded.
+func (r *EnvironmentResource) setEnvironmentStatus(ctx context.Context, diags *diag.Diagnostics, projectID string, env *platformsh.Environment, target string) *platformsh.Environment {
+	for i := 0; env.Status != target; i++ {
+		if env.Status == platformsh.EnvironmentStatusDirty {
+			var err error
+			env, err = r.client.WaitForEnvironment(ctx, projectID, env.ID)
+			if err != nil {
+				addClientError(diags, "wait for environment", err)
+				return nil
+			}
+			if env.Status == target {
+				break
+			}
+		}
+
+		step, ok := nextStatusTransition(r.client, env.Status, target)
+		if !ok || i == maxStatusTransitions {
+			diags.AddError(
+				"Unable to Change Environment Status",
+				fmt.Sprintf("Environment %q cannot be made %s while its status is %q. Wait for running activities to finish and try again.", env.ID, target, env.Status),
+			)
+			return nil
+		}
+		if !checkEnvironmentAllows(diags, env, step.rel, step.action) {
+			return nil
+		}
+
+		operation, err := step.run(ctx, projectID, env.ID)
+		if err != nil {
+			addClientError(diags, step.action+" environment", err)
+			return nil
+		}
+		if _, err := r.client.WaitForOperation(ctx, projectID, operation); err != nil {
+			addClientError(diags, step.action+" environment", err)
+			return nil
+		}
+
+		env, err = r.client.GetEnvironment(ctx, projectID, env.ID)
+		if err != nil {
+			addClientError(diags, "read environment after status change", err)
+			return nil
+		}
+	}
+
+	return env
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEnvironmentImportState(t *testing.T) {
+	tests := []struct {
+		name             string
+		id               string
+		defaultProjectID types.String
+
+		wantProjectID     string
+		wantEnvironmentID string
+		// wantErr is part of the expected error detail, if any.
+		wantErr string
+	}{
+		{
+			name:              "project and environment with a slash",
+			id:                "proj:feature/login",
+			defaultProjectID:  types.StringNull(),
+			wantProjectID:     "proj",
+			wantEnvironmentID: "feature/login",
+		},
+		{
+			name:              "project in the ID wins over the default",
+			id:                "proj:main",
+			defaultProjectID:  types.StringValue("default"),
+			wantProjectID:     "proj",
+			wantEnvironmentID: "main",
+		},
+		{
+			name:              "bare ID with a default project",
+			id:                "feature/login",
+			defaultProjectID:  types.StringValue("default"),
+			wantProjectID:     "default",
+			wantEnvironmentID: "feature/login",
+		},
+		{
+			name:             "bare ID without a default project",
+			id:               "feature/login",
+			defaultProjectID: types.StringNull(),
+			wantErr:          "sets a default project_id",
+		},
+		{
+			name:             "bare ID with an unknown default project",
+			id:               "feature/login",
+			defaultProjectID: types.StringUnknown(),
+			wantErr:          "not known yet",
+		},
+		{
+			name:             "empty project",
+			id:               ":feature/login",
+			defaultProjectID: types.StringValue("default"),
+			wantErr:          "<project_id>:<environment_id>",
+		},
+		{
+			name:             "empty environment",
+			id:               "proj:",
+			defaultProjectID: types.StringValue("default"),
+			wantErr:          "<project_id>:<environment_id>",
+		},
+		{
+			name:             "empty ID",
+			id:               "",
+			defaultProjectID: types.StringValue("default"),
+			wantErr:          "<project_id>:<environment_id>",
+		},
+	}
+
+	ctx := context.Background()
+	r := &EnvironmentResource{}
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.defaultProjectID = tt.defaultProjectID
+
+			// Import starts from an empty state.
+			resp := resource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			resp.Diagnostics.Append(resp.State.Set(ctx, &EnvironmentResourceModel{})...)
+			r.ImportState(ctx, resource.ImportStateRequest{ID: tt.id}, &resp)
+
+			if tt.wantErr != "" {
+				if !resp.Diagnostics.HasError() {
+					t.Fatalf("ImportState(%q) succeeded, want an error", tt.id)
+				}
+				if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, tt.wantErr) {
+					t.Fatalf("ImportState(%q) error = %q, want it to mention %q", tt.id, detail, tt.wantErr)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("ImportState(%q): %v", tt.id, resp.Diagnostics)
+			}
+
+			var got EnvironmentResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &got)...)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+			if got.ProjectID.ValueString() != tt.wantProjectID || got.ID.ValueString() != tt.wantEnvironmentID {
+				t.Errorf("ImportState(%q) = project %s, environment %s; want %q, %q", tt.id, got.ProjectID, got.ID, tt.wantProjectID, tt.wantEnvironmentID)
+			}
+			if !got.CloneParent.ValueBool() || got.KeepInactiveOnDestroy.ValueBool() {
+				t.Errorf("ImportState(%q) did not start from the defaults: clone_parent = %s, keep_inactive_on_destroy = %s", tt.id, got.CloneParent, got.KeepInactiveOnDestroy)
+			}
+		})
+	}
+}
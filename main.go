@@ -8,8 +8,13 @@ import (
 	"github.com/rubaiat-hossain/terraform-provider-platformsh/internal/provider"
 )
 
+// version is set at build time, e.g.
+//
+//	go build -ldflags "-X main.version=0.2.0"
+var version = "dev"
+
 func main() {
-	err := providerserver.Serve(context.Background(), provider.New, providerserver.ServeOpts{
+	err := providerserver.Serve(context.Background(), provider.New(version), providerserver.ServeOpts{
 		Address: "local.provider/rhs/platformsh",
 	})
 	if err != nil {
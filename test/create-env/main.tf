@@ -7,9 +7,8 @@ terraform {
   }
 }
 
-provider "platformsh" {
-  api_token = "YOUR_API_KEY"
-}
+# The API token is read from the PLATFORMSH_CLI_TOKEN environment variable.
+provider "platformsh" {}
 
 resource "platformsh_environment" "new_environment" {
   project_id      = "PROJECT_ID"
@@ -0,0 +1,28 @@
+# Run against the fake API:
+#   go run ./cmd/platformsh-fake -fixture test/fake-api/fixture.json
+terraform {
+  required_providers {
+    platformsh = {
+      source = "local.provider/rhs/platformsh"
+    }
+  }
+}
+
+provider "platformsh" {
+  api_token = "fake-api-token"
+  api_url   = "http://127.0.0.1:8080"
+  auth_url  = "http://127.0.0.1:8080"
+
+  project_id = "fakeproject1"
+}
+
+resource "platformsh_environment" "new_environment" {
+  name  = "test-env"
+  title = "test-environment"
+}
+
+data "platformsh_environments" "example" {}
+
+output "environment_ids" {
+  value = [for e in data.platformsh_environments.example.environments : e.id]
+}
@@ -6,9 +6,8 @@ terraform {
   }
 }
 
-provider "platformsh" {
-  api_token = "YOUR_API_KEY"
-}
+# The API token is read from the PLATFORMSH_CLI_TOKEN environment variable.
+provider "platformsh" {}
 
 data "platformsh_environments" "example" {
   project_id = "PROJECT_ID"